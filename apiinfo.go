@@ -0,0 +1,127 @@
+package goapi
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-errors/errors"
+)
+
+// APIInfo is the metadata of OpenAPI document, empty fields keep the current values
+type APIInfo struct {
+	Title          string
+	Version        string
+	Description    string
+	TermsOfService string
+	Contact        *openapi3.Contact
+	License        *openapi3.License
+	// Servers are the base URLs of API like "https://api.example.com"
+	Servers []APIServer
+	// Tags describe the tags of services, the tag of a service is its name
+	Tags         []APITag
+	ExternalDocs *openapi3.ExternalDocs
+	// Extensions are added to the document, keys should start with "x-"
+	Extensions map[string]interface{}
+}
+
+// APIServer is a server of OpenAPI document
+type APIServer struct {
+	URL         string
+	Description string
+}
+
+// APITag is a top-level tag of OpenAPI document
+type APITag struct {
+	Name         string
+	Description  string
+	ExternalDocs *openapi3.ExternalDocs
+}
+
+// ServiceDescription can be implemented by a service to describe its tag in OpenAPI document
+type ServiceDescription interface {
+	Description() string
+}
+
+// SetAPIInfo sets the metadata of OpenAPI document
+func (s *Server) SetAPIInfo(info APIInfo) *Server {
+	s.checkError(s.api.setInfo(info))
+	return s
+}
+
+func (o *openapi) setInfo(info APIInfo) error {
+	for k := range info.Extensions {
+		if !strings.HasPrefix(k, "x-") {
+			return errors.Errorf("extension %s of API info should start with x-", k)
+		}
+	}
+	for _, tag := range info.Tags {
+		if tag.Name == "" {
+			return errors.Errorf("name of API tag %q is empty", tag.Description)
+		}
+	}
+	model := o.model
+	if info.Title != "" {
+		model.Info.Title = info.Title
+	}
+	if info.Version != "" {
+		model.Info.Version = info.Version
+	}
+	if info.Description != "" {
+		model.Info.Description = info.Description
+	}
+	if info.TermsOfService != "" {
+		model.Info.TermsOfService = info.TermsOfService
+	}
+	if info.Contact != nil {
+		model.Info.Contact = info.Contact
+	}
+	if info.License != nil {
+		model.Info.License = info.License
+	}
+	if info.ExternalDocs != nil {
+		model.ExternalDocs = info.ExternalDocs
+	}
+	if len(info.Servers) > 0 {
+		model.Servers = nil
+		for _, sv := range info.Servers {
+			model.Servers = append(model.Servers, &openapi3.Server{URL: sv.URL, Description: sv.Description})
+		}
+	}
+	for _, tag := range info.Tags {
+		t := o.addTag(tag.Name)
+		t.Description = tag.Description
+		t.ExternalDocs = tag.ExternalDocs
+	}
+	if len(info.Extensions) > 0 && model.Extensions == nil {
+		model.Extensions = map[string]interface{}{}
+	}
+	for k, v := range info.Extensions {
+		model.Extensions[k] = v
+	}
+	return nil
+}
+
+// addTag returns top-level tag of name, it is added if not exists
+func (o *openapi) addTag(name string) *openapi3.Tag {
+	if t := o.model.Tags.Get(name); t != nil {
+		return t
+	}
+	t := &openapi3.Tag{Name: name}
+	o.model.Tags = append(o.model.Tags, t)
+	return t
+}
+
+// addServiceTag adds the tag of service, description of ServiceDescription or
+// doc comment is used unless it's set by SetAPIInfo
+func (o *openapi) addServiceTag(name string, sv interface{}) {
+	t := o.addTag(name)
+	if t.Description != "" {
+		return
+	}
+	if sd, ok := sv.(ServiceDescription); ok {
+		t.Description = sd.Description()
+	} else {
+		t.Description = lookupDoc(reflect.TypeOf(sv), "")
+	}
+}
@@ -0,0 +1,61 @@
+package goapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+)
+
+type DescribedService struct{}
+
+func (s *DescribedService) Description() string {
+	return "Greeting service"
+}
+
+func (s *DescribedService) Hello(ctx context.Context, req *optionsReq, rsp *optionsRsp) error {
+	return nil
+}
+
+func TestAPIInfo(t *testing.T) {
+	t.Setenv("SERVE_API_TITLE", "Greeting API")
+	t.Setenv("SERVE_API_SERVERS", "https://api.example.com, https://staging.example.com")
+	t.Setenv("SERVE_API_TAGS", "Billing:Invoices and payments,Audit:Audit logs")
+	t.Setenv("SERVE_API_EXTENSIONS", "x-logo:https://example.com/logo.png")
+	s := NewServer()
+	s.SetAPIInfo(APIInfo{
+		Version: "2.1.0",
+		License: &openapi3.License{Name: "MIT"},
+		Tags:    []APITag{{Name: "Admin", Description: "Administration"}},
+		Extensions: map[string]interface{}{
+			"x-portal-team": "platform",
+		},
+	})
+	s.RegisterService(&DescribedService{})
+
+	doc := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(s.api.getOpenAPIV3(), &doc))
+	assert.Equal(t, map[string]interface{}{
+		"title":   "Greeting API",
+		"version": "2.1.0",
+		"license": map[string]interface{}{"name": "MIT"},
+	}, doc["info"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"url": "https://api.example.com"},
+		map[string]interface{}{"url": "https://staging.example.com"},
+	}, doc["servers"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "Billing", "description": "Invoices and payments"},
+		map[string]interface{}{"name": "Audit", "description": "Audit logs"},
+		map[string]interface{}{"name": "Admin", "description": "Administration"},
+		map[string]interface{}{"name": "DescribedService", "description": "Greeting service"},
+	}, doc["tags"])
+	assert.Equal(t, "platform", doc["x-portal-team"])
+	assert.Equal(t, "https://example.com/logo.png", doc["x-logo"])
+
+	assert.NotNil(t, s.api.setInfo(APIInfo{Extensions: map[string]interface{}{"portal": 1}}))
+	cfg := &serveConfig{APIExtensions: []string{"logo:x.png"}}
+	assert.EqualError(t, s.api.setInfo(cfg.apiInfo()), "extension logo of API info should start with x-")
+}
@@ -0,0 +1,57 @@
+package goapi
+
+import (
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-errors/errors"
+	"github.com/ottstack/goapi/pkg/middleware"
+)
+
+// UseAuth adds global authentication middleware and documents the security
+// schemes, methods require authentication by MethodOptions.Security. Serve
+// fails if MethodOptions.Security names a scheme not added by UseAuth.
+func (s *Server) UseAuth(authenticators ...middleware.Authenticator) *Server {
+	for _, a := range authenticators {
+		s.api.addSecurityScheme(a.Name(), a.Scheme())
+	}
+	return s.Use(middleware.Authenticate(authenticators...))
+}
+
+func (o *openapi) addSecurityScheme(name string, scheme middleware.SecurityScheme) {
+	if o.model.Components.SecuritySchemes == nil {
+		o.model.Components.SecuritySchemes = openapi3.SecuritySchemes{}
+	}
+	o.model.Components.SecuritySchemes[name] = &openapi3.SecuritySchemeRef{Value: &openapi3.SecurityScheme{
+		Type:         scheme.Type,
+		Scheme:       scheme.Scheme,
+		BearerFormat: scheme.BearerFormat,
+		In:           scheme.In,
+		Name:         scheme.Name,
+		Description:  scheme.Description,
+	}}
+}
+
+// checkSecurity returns error if security requirements of operations name schemes not added by UseAuth
+func (s *Server) checkSecurity() error {
+	paths := make([]string, 0, len(s.api.model.Paths))
+	for path := range s.api.model.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, oper := range s.api.model.Paths[path].Operations() {
+			if oper.Security == nil {
+				continue
+			}
+			for _, requirement := range *oper.Security {
+				for name := range requirement {
+					if _, ok := s.api.model.Components.SecuritySchemes[name]; !ok {
+						return errors.Errorf("security scheme %s of %s is not added by UseAuth", name, oper.OperationID)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
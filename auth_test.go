@@ -0,0 +1,26 @@
+package goapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ottstack/goapi/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSecurity(t *testing.T) {
+	s := NewServer()
+	s.RegisterService(&OptionsService{})
+	assert.EqualError(t, s.checkSecurity(), "security scheme bearer of OptionsServiceHello is not added by UseAuth")
+	assert.EqualError(t, s.ServeContext(context.Background()), "security scheme bearer of OptionsServiceHello is not added by UseAuth")
+
+	s = NewServer()
+	s.RegisterService(&OptionsService{})
+	s.UseAuth(middleware.NewAPIKeyAuth("key", middleware.APIKeyConfig{}))
+	assert.EqualError(t, s.checkSecurity(), "security scheme bearer of OptionsServiceHello is not added by UseAuth")
+
+	jwtAuth, err := middleware.NewJWTAuth("bearer", middleware.JWTConfig{Secret: []byte("secret")})
+	assert.NoError(t, err)
+	s.UseAuth(jwtAuth)
+	assert.NoError(t, s.checkSecurity())
+}
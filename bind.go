@@ -0,0 +1,208 @@
+package goapi
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	pathTag   = "path"
+	queryTag  = "query"
+	headerTag = "header"
+	cookieTag = "cookie"
+)
+
+// bindTags are the struct tags of request fields bound from outside of body,
+// the tag name is also the "in" of OpenAPI parameter
+var bindTags = []string{pathTag, queryTag, headerTag, cookieTag}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+type boundField struct {
+	index []int
+	in    string
+	name  string
+	field reflect.StructField
+}
+
+// parseBoundFields collects the fields of request struct bound from path, query, header or cookie,
+// fields of embedded structs are collected like the inherited fields of schema
+func parseBoundFields(st reflect.Type) []boundField {
+	var fields []boundField
+	for i := 0; i < st.NumField(); i++ {
+		field := st.Field(i)
+		if in, name := boundTag(field); in != "" {
+			if field.IsExported() {
+				fields = append(fields, boundField{index: field.Index, in: in, name: name, field: field})
+			}
+			continue
+		}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			// pointer to unexported struct can't be allocated
+			if !field.IsExported() {
+				continue
+			}
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			for _, f := range parseBoundFields(fieldType) {
+				f.index = append([]int{i}, f.index...)
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
+
+// boundTag returns where the field is bound from and the parameter name
+func boundTag(field reflect.StructField) (string, string) {
+	for _, tag := range bindTags {
+		name := field.Tag.Get(tag)
+		if name != "" && name != "-" {
+			return tag, name
+		}
+	}
+	return "", ""
+}
+
+// bindParams sets path parameters, query args, headers and cookies into request struct,
+// the bound fields are reset if the parameters are absent
+func bindParams(fastReq *fasthttp.RequestCtx, fields []boundField, req interface{}) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	v := reflect.ValueOf(req).Elem()
+	for _, f := range fields {
+		// the value decoded from body is dropped, bound fields are only set by parameters
+		if field, err := v.FieldByIndexErr(f.index); err == nil {
+			field.Set(reflect.Zero(field.Type()))
+		}
+		values := lookupParam(fastReq, f)
+		if len(values) == 0 {
+			continue
+		}
+		if err := setValues(fieldByIndex(v, f.index), values); err != nil {
+			return &ecode.APIError{Code: 400, Message: fmt.Sprintf("Invalid %s parameter %s: %v", f.in, f.name, err)}
+		}
+	}
+	return nil
+}
+
+// fieldByIndex returns nested field of v, nil pointers of embedded structs are allocated
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+func lookupParam(fastReq *fasthttp.RequestCtx, f boundField) []string {
+	switch f.in {
+	case pathTag:
+		if val, ok := fastReq.UserValue(f.name).(string); ok {
+			return []string{val}
+		}
+	case queryTag:
+		var values []string
+		for _, val := range fastReq.QueryArgs().PeekMulti(f.name) {
+			values = append(values, string(val))
+		}
+		return values
+	case headerTag:
+		if val := fastReq.Request.Header.Peek(f.name); len(val) > 0 {
+			if isSliceField(f.field.Type) {
+				return splitList(string(val))
+			}
+			return []string{string(val)}
+		}
+	case cookieTag:
+		if val := fastReq.Request.Header.Cookie(f.name); len(val) > 0 {
+			return []string{string(val)}
+		}
+	}
+	return nil
+}
+
+func isSliceField(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && !t.Implements(textUnmarshalerType) && !reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, val := range strings.Split(s, ",") {
+		if val = strings.TrimSpace(val); val != "" {
+			values = append(values, val)
+		}
+	}
+	return values
+}
+
+func setValues(v reflect.Value, values []string) error {
+	if isSliceField(v.Type()) {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, val := range values {
+			if err := setValue(slice.Index(i), val); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return setValue(v, values[len(values)-1])
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package goapi
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type bindRequest struct {
+	ID      int64     `path:"id"`
+	Page    *int      `query:"page"`
+	Tags    []string  `query:"tag"`
+	Since   time.Time `query:"since"`
+	Tenant  string    `header:"X-Tenant-ID"`
+	Flags   []bool    `header:"X-Flags"`
+	Session string    `cookie:"sid"`
+	Name    string    `json:"name"`
+}
+
+func TestBindParams(t *testing.T) {
+	fastReq := &fasthttp.RequestCtx{}
+	fastReq.Request.SetRequestURI("/users/12?page=3&tag=a&tag=b&since=2023-01-02T03:04:05Z")
+	fastReq.Request.Header.Set("X-Tenant-ID", "t1")
+	fastReq.Request.Header.Set("X-Flags", "true, false")
+	fastReq.Request.Header.SetCookie("sid", "s1")
+	fastReq.SetUserValue("id", "12")
+
+	fields := parseBoundFields(reflect.TypeOf(bindRequest{}))
+	assert.Len(t, fields, 7)
+
+	req := &bindRequest{}
+	assert.Nil(t, bindParams(fastReq, fields, req))
+	assert.Equal(t, int64(12), req.ID)
+	assert.Equal(t, 3, *req.Page)
+	assert.Equal(t, []string{"a", "b"}, req.Tags)
+	assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), req.Since)
+	assert.Equal(t, "t1", req.Tenant)
+	assert.Equal(t, []bool{true, false}, req.Flags)
+	assert.Equal(t, "s1", req.Session)
+
+	fastReq.Request.SetRequestURI("/users/12?page=x")
+	err := bindParams(fastReq, fields, &bindRequest{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Code: 400")
+}
+
+type Paging struct {
+	Page int `query:"page"`
+	Size int `query:"size"`
+}
+
+type tracing struct {
+	TraceID string `header:"X-Trace-ID"`
+}
+
+type embeddedRequest struct {
+	Paging
+	*tracing
+	Owner *Owner
+	Name  string `json:"name"`
+}
+
+type Owner struct {
+	ID int `path:"id"`
+}
+
+func TestBindEmbeddedParams(t *testing.T) {
+	fields := parseBoundFields(reflect.TypeOf(embeddedRequest{}))
+	assert.Len(t, fields, 2)
+	assert.Equal(t, []int{0, 0}, fields[0].index)
+	assert.Equal(t, "size", fields[1].name)
+
+	fastReq := &fasthttp.RequestCtx{}
+	fastReq.Request.SetRequestURI("/items?page=2&size=10&size=20")
+	req := &embeddedRequest{}
+	assert.Nil(t, bindParams(fastReq, fields, req))
+	assert.Equal(t, 2, req.Page)
+	// the last one of repeated values is bound into scalar field
+	assert.Equal(t, 20, req.Size)
+	assert.Nil(t, req.Owner)
+}
+
+func TestBindMissingAndMalformedParams(t *testing.T) {
+	fields := parseBoundFields(reflect.TypeOf(bindRequest{}))
+
+	fastReq := &fasthttp.RequestCtx{}
+	fastReq.Request.SetRequestURI("/users")
+	req := &bindRequest{Name: "kept"}
+	assert.Nil(t, bindParams(fastReq, fields, req))
+	assert.Nil(t, req.Page)
+	assert.Nil(t, req.Tags)
+	assert.Equal(t, "", req.Tenant)
+	assert.Equal(t, "kept", req.Name)
+
+	for name, setup := range map[string]func(*fasthttp.RequestCtx){
+		"path parameter id":        func(r *fasthttp.RequestCtx) { r.SetUserValue("id", "twelve") },
+		"query parameter since":    func(r *fasthttp.RequestCtx) { r.Request.SetRequestURI("/users?since=yesterday") },
+		"header parameter X-Flags": func(r *fasthttp.RequestCtx) { r.Request.Header.Set("X-Flags", "true,maybe") },
+	} {
+		fastReq := &fasthttp.RequestCtx{}
+		fastReq.Request.SetRequestURI("/users")
+		setup(fastReq)
+		err := bindParams(fastReq, fields, &bindRequest{})
+		apiErr, ok := err.(*ecode.APIError)
+		if assert.True(t, ok, name) {
+			assert.Equal(t, 400, apiErr.Code)
+			assert.Contains(t, apiErr.Message, "Invalid "+name)
+		}
+	}
+}
+
+func TestBodyCannotSetBoundFields(t *testing.T) {
+	fields := parseBoundFields(reflect.TypeOf(embeddedRequest{}))
+	req := &bindRequest{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"ID":7,"Tenant":"spoofed","Session":"s","name":"alice"}`), req))
+	assert.Equal(t, "spoofed", req.Tenant)
+
+	fastReq := &fasthttp.RequestCtx{}
+	fastReq.Request.SetRequestURI("/users")
+	assert.Nil(t, bindParams(fastReq, parseBoundFields(reflect.TypeOf(bindRequest{})), req))
+	assert.Equal(t, int64(0), req.ID)
+	assert.Equal(t, "", req.Tenant)
+	assert.Equal(t, "", req.Session)
+	assert.Equal(t, "alice", req.Name)
+
+	embedded := &embeddedRequest{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"Page":9}`), embedded))
+	assert.Nil(t, bindParams(fastReq, fields, embedded))
+	assert.Equal(t, 0, embedded.Page)
+	assert.Nil(t, embedded.tracing)
+}
+
+type RouteService struct{}
+
+func (s *RouteService) Routes() map[string]string {
+	return map[string]string{"Get": "GET users/{id}"}
+}
+
+func (s *RouteService) Get(ctx context.Context, req *bindRequest, rsp *optionsRsp) error {
+	return nil
+}
+
+func (s *RouteService) Post(ctx context.Context, req *bindRequest, rsp *optionsRsp) error {
+	return nil
+}
+
+func TestPathFieldsOfRoute(t *testing.T) {
+	s := NewServer()
+	err := s.parse(s.root, []interface{}{&RouteService{}})
+	assert.EqualError(t, err, "field ID with tag `path:\"id\"` in goapi.bindRequest has no path parameter in /api/RouteService/Post")
+}
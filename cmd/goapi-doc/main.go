@@ -0,0 +1,300 @@
+// Command goapi-doc generates a file registering the doc comments of the struct
+// types, fields and methods in a package, goapi uses them to document the
+// services and schemas in OpenAPI document. Exported constants of string or
+// integer enum types are registered as their enum values, a type is enum if
+// it's marked by //goapi:enum or all its constants are in one const block
+// without constants of other types. Add the directive to the package of services:
+//
+//	//go:generate go run github.com/ottstack/goapi/cmd/goapi-doc
+//
+// Flags:
+//
+//	-dir    directory of package, default is current directory
+//	-o      output file name, default is goapi_doc.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pkgDoc is the doc comments and enums of a package
+type pkgDoc struct {
+	name  string
+	types []*typeDoc
+	enums []*enumDoc
+}
+
+// typeDoc is the doc comments of a struct type
+type typeDoc struct {
+	name    string
+	doc     string
+	members map[string]string
+}
+
+// enumDoc is the constants of a string or integer type
+type enumDoc struct {
+	name   string
+	values []string
+}
+
+// enumMarker marks a type as enum in its doc comment
+const enumMarker = "//goapi:enum"
+
+// underlying types of enum
+var enumKinds = map[string]bool{
+	"string": true, "int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+}
+
+func main() {
+	dir := flag.String("dir", ".", "directory of package")
+	output := flag.String("o", "goapi_doc.go", "output file name")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("goapi-doc: ")
+
+	doc, err := parseDir(*dir, *output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(*dir, *output), src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseDir collects doc comments of the struct types and their exported fields and methods,
+// and the exported constants of enum types. Test files, the output file and files excluded
+// by build constraints are skipped.
+func parseDir(dir, output string) (*pkgDoc, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		if strings.HasSuffix(fi.Name(), "_test.go") || fi.Name() == output {
+			return false
+		}
+		match, err := build.Default.MatchFile(dir, fi.Name())
+		return match || err != nil
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expect one package in %s, found %d", dir, len(pkgs))
+	}
+	var pkg *ast.Package
+	for _, p := range pkgs {
+		pkg = p
+	}
+	fileNames := make([]string, 0, len(pkg.Files))
+	for name := range pkg.Files {
+		fileNames = append(fileNames, name)
+	}
+	sort.Strings(fileNames)
+
+	types := map[string]*typeDoc{}
+	basicTypes := map[string]bool{}
+	markedTypes := map[string]bool{}
+	methods := map[string]map[string]string{}
+	constants := map[string][]string{}
+	// const blocks declaring constants of each type, and the number of types in each block
+	constBlocks := map[string]map[*ast.GenDecl]bool{}
+	blockTypes := map[*ast.GenDecl]int{}
+	for _, fileName := range fileNames {
+		for _, decl := range pkg.Files[fileName].Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				switch decl.Tok {
+				case token.TYPE:
+					for _, spec := range decl.Specs {
+						ts := spec.(*ast.TypeSpec)
+						if ts.TypeParams != nil || ts.Assign.IsValid() {
+							continue
+						}
+						doc := ts.Doc
+						if doc == nil && len(decl.Specs) == 1 {
+							doc = decl.Doc
+						}
+						if ident, ok := ts.Type.(*ast.Ident); ok && enumKinds[ident.Name] {
+							basicTypes[ts.Name.Name] = true
+							markedTypes[ts.Name.Name] = hasMarker(doc)
+						}
+						st, ok := ts.Type.(*ast.StructType)
+						if !ok {
+							continue
+						}
+						types[ts.Name.Name] = &typeDoc{name: ts.Name.Name, doc: doc.Text(), members: fieldDocs(st)}
+					}
+				case token.CONST:
+					typeName := ""
+					typeNames := map[string]bool{}
+					for _, spec := range decl.Specs {
+						vs := spec.(*ast.ValueSpec)
+						if ident, ok := vs.Type.(*ast.Ident); ok {
+							typeName = ident.Name
+						} else if vs.Type != nil || len(vs.Values) > 0 {
+							// untyped or not a local type, constants without value repeat the last type
+							typeName = ""
+						}
+						typeNames[typeName] = true
+						if typeName != "" {
+							if constBlocks[typeName] == nil {
+								constBlocks[typeName] = map[*ast.GenDecl]bool{}
+							}
+							constBlocks[typeName][decl] = true
+						}
+						for _, name := range vs.Names {
+							if typeName != "" && name.IsExported() {
+								constants[typeName] = append(constants[typeName], name.Name)
+							}
+						}
+					}
+					blockTypes[decl] = len(typeNames)
+				}
+			case *ast.FuncDecl:
+				if decl.Recv == nil || len(decl.Recv.List) != 1 || !decl.Name.IsExported() {
+					continue
+				}
+				recv := receiverName(decl.Recv.List[0].Type)
+				if recv == "" {
+					continue
+				}
+				if methods[recv] == nil {
+					methods[recv] = map[string]string{}
+				}
+				methods[recv][decl.Name.Name] = decl.Doc.Text()
+			}
+		}
+	}
+
+	doc := &pkgDoc{name: pkg.Name}
+	for name, t := range types {
+		for method, text := range methods[name] {
+			if text != "" {
+				t.members[method] = text
+			}
+		}
+		if t.doc != "" || len(t.members) > 0 {
+			doc.types = append(doc.types, t)
+		}
+	}
+	sort.Slice(doc.types, func(i, j int) bool { return doc.types[i].name < doc.types[j].name })
+
+	for name, values := range constants {
+		// types with Enum method list the values by themselves
+		if _, ok := methods[name]["Enum"]; ok || !basicTypes[name] {
+			continue
+		}
+		if !markedTypes[name] && !inOneBlock(constBlocks[name], blockTypes) {
+			continue
+		}
+		doc.enums = append(doc.enums, &enumDoc{name: name, values: values})
+	}
+	sort.Slice(doc.enums, func(i, j int) bool { return doc.enums[i].name < doc.enums[j].name })
+	return doc, nil
+}
+
+// hasMarker returns whether doc comment contains enumMarker
+func hasMarker(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == enumMarker {
+			return true
+		}
+	}
+	return false
+}
+
+// inOneBlock returns whether constants of a type are declared in one const block
+// which has no constants of other types
+func inOneBlock(blocks map[*ast.GenDecl]bool, blockTypes map[*ast.GenDecl]int) bool {
+	if len(blocks) != 1 {
+		return false
+	}
+	for block := range blocks {
+		return blockTypes[block] == 1
+	}
+	return false
+}
+
+// fieldDocs returns doc or line comments of exported fields
+func fieldDocs(st *ast.StructType) map[string]string {
+	docs := map[string]string{}
+	for _, field := range st.Fields.List {
+		doc := field.Doc.Text()
+		if doc == "" {
+			doc = field.Comment.Text()
+		}
+		if doc == "" {
+			continue
+		}
+		for _, name := range field.Names {
+			if name.IsExported() {
+				docs[name.Name] = doc
+			}
+		}
+	}
+	return docs
+}
+
+// receiverName returns type name of receiver like T or *T
+func receiverName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+func generate(doc *pkgDoc) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by goapi-doc. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", doc.name)
+	buf.WriteString("import \"github.com/ottstack/goapi\"\n\n")
+	buf.WriteString("func init() {\n")
+	for _, t := range doc.types {
+		fmt.Fprintf(&buf, "goapi.RegisterDoc((*%s)(nil), %s, ", t.name, strconv.Quote(strings.TrimSpace(t.doc)))
+		if len(t.members) == 0 {
+			buf.WriteString("nil)\n")
+			continue
+		}
+		names := make([]string, 0, len(t.members))
+		for name := range t.members {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		buf.WriteString("map[string]string{\n")
+		for _, name := range names {
+			fmt.Fprintf(&buf, "%s: %s,\n", strconv.Quote(name), strconv.Quote(strings.TrimSpace(t.members[name])))
+		}
+		buf.WriteString("})\n")
+	}
+	for _, e := range doc.enums {
+		fmt.Fprintf(&buf, "goapi.RegisterEnum((*%s)(nil),\n", e.name)
+		for _, value := range e.values {
+			fmt.Fprintf(&buf, "goapi.EnumValue{Name: %s, Value: %s},\n", strconv.Quote(value), value)
+		}
+		buf.WriteString(")\n")
+	}
+	buf.WriteString("}\n")
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGenerate(t *testing.T) {
+	for _, name := range []string{"service", "constraints", "enums"} {
+		t.Run(name, func(t *testing.T) {
+			doc, err := parseDir(filepath.Join("testdata", name), "goapi_doc.go")
+			if err != nil {
+				t.Fatal(err)
+			}
+			src, err := generate(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, src, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, string(want), string(src))
+		})
+	}
+}
+
+func TestParseDirEnums(t *testing.T) {
+	doc, err := parseDir(filepath.Join("testdata", "enums"), "goapi_doc.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var enums []string
+	for _, e := range doc.enums {
+		enums = append(enums, e.name)
+	}
+	assert.Equal(t, []string{"Color", "Size"}, enums)
+}
+
+func TestParseDirError(t *testing.T) {
+	_, err := parseDir(filepath.Join("testdata", "missing"), "goapi_doc.go")
+	assert.Error(t, err)
+	_, err = parseDir("testdata", "goapi_doc.go")
+	assert.EqualError(t, err, "expect one package in testdata, found 0")
+}
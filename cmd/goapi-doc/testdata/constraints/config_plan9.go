@@ -0,0 +1,4 @@
+package constraints
+
+// Plan9Config is excluded by file name
+type Plan9Config struct{}
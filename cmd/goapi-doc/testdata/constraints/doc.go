@@ -0,0 +1,7 @@
+package constraints
+
+// Config is the configuration of every platform
+type Config struct {
+	// Addr is the listen address
+	Addr string
+}
@@ -0,0 +1,4 @@
+package constraints
+
+// Generated is skipped since it is the output file
+type Generated struct{}
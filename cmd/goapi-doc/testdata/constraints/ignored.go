@@ -0,0 +1,6 @@
+//go:build ignore
+
+package main
+
+// Ignored is excluded by build constraint
+type Ignored struct{}
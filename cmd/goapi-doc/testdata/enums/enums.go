@@ -0,0 +1,54 @@
+package enums
+
+// Color is enum since its constants are in one block
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+	black Color = -1
+)
+
+// Size is enum by the marker although its constants are scattered
+//
+//goapi:enum
+type Size string
+
+const Small Size = "S"
+
+const (
+	Large Size = "L"
+	// MaxRetries is not a Size
+	MaxRetries = 3
+)
+
+// Timeout is not enum since its constants are mixed with others
+type Timeout int64
+
+const (
+	DefaultTimeout Timeout = 30
+	DefaultPort            = 8080
+)
+
+// Mode is not enum since its constants are in separate blocks
+type Mode string
+
+const ModeRead Mode = "r"
+
+const ModeWrite Mode = "w"
+
+// Shape lists its values by Enum method
+type Shape string
+
+const (
+	Circle Shape = "circle"
+	Square Shape = "square"
+)
+
+func (Shape) Enum() []string { return nil }
+
+// Options is a struct documented with its fields
+type Options struct {
+	Color Color // color of item
+}
@@ -0,0 +1,50 @@
+package service
+
+import "context"
+
+// GreetService greets the callers
+type GreetService struct{}
+
+// Hello says hello to the caller
+//
+// The greeting is localized by Accept-Language.
+func (s *GreetService) Hello(ctx context.Context, req *HelloRequest, rsp *HelloResponse) error {
+	return nil
+}
+
+func (s *GreetService) unexported() {}
+
+// HelloRequest is the request of Hello
+type HelloRequest struct {
+	// Name of the caller
+	Name string `json:"name"`
+	Lang Lang   `json:"lang"` // language of greeting
+	note string // unexported fields are skipped
+}
+
+type (
+	// HelloResponse is the greeting
+	HelloResponse struct {
+		Message string `json:"message"`
+	}
+	undocumented struct {
+		Value int
+	}
+)
+
+// Lang is a language of greeting
+type Lang string
+
+const (
+	English Lang = "en"
+	Chinese Lang = "zh"
+	unknown Lang = "?"
+)
+
+// Level is a level of greeting
+type Level int
+
+const (
+	Low Level = iota
+	High
+)
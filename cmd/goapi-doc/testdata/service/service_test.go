@@ -0,0 +1,4 @@
+package service
+
+// TestOnly is skipped since it is in test file
+type TestOnly struct{}
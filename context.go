@@ -0,0 +1,91 @@
+package goapi
+
+import (
+	"context"
+	"net"
+
+	"github.com/ottstack/goapi/pkg/middleware"
+	"github.com/valyala/fasthttp"
+)
+
+// requestCtxKey is the user value key of request itself,
+// so that request can be found from the context wrapped by middlewares
+type requestCtxKey struct{}
+
+type requestInfoKey struct{}
+
+// RequestInfo describes the request being served
+type RequestInfo struct {
+	// HTTP method
+	Method string
+	// Request path
+	Path string
+	// Name of listener accepting the request
+	Listener string
+	// Route being called
+	*middleware.CallInfo
+
+	fastReq *fasthttp.RequestCtx
+}
+
+// RemoteIP returns the IP of peer connection
+func (r *RequestInfo) RemoteIP() net.IP {
+	return r.fastReq.RemoteIP()
+}
+
+// Header returns the request header value of key
+func (r *RequestInfo) Header(key string) string {
+	return string(r.fastReq.Request.Header.Peek(key))
+}
+
+// Cookie returns the request cookie value of key
+func (r *RequestInfo) Cookie(key string) string {
+	return string(r.fastReq.Request.Header.Cookie(key))
+}
+
+// Query returns the query argument value of key
+func (r *RequestInfo) Query(key string) string {
+	return string(r.fastReq.QueryArgs().Peek(key))
+}
+
+// RequestCtx returns the underlying fasthttp request
+func (r *RequestInfo) RequestCtx() *fasthttp.RequestCtx {
+	return r.fastReq
+}
+
+func requestCtx(ctx context.Context) *fasthttp.RequestCtx {
+	if fastReq, ok := ctx.(*fasthttp.RequestCtx); ok {
+		return fastReq
+	}
+	fastReq, _ := ctx.Value(requestCtxKey{}).(*fasthttp.RequestCtx)
+	return fastReq
+}
+
+// RequestFromContext returns the request info of context passed to handlers and
+// middlewares, it still works after the context is wrapped by context.WithValue.
+func RequestFromContext(ctx context.Context) (*RequestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info, ok
+}
+
+// SetResponseHeader sets header of the response
+func SetResponseHeader(ctx context.Context, key, value string) {
+	if fastReq := requestCtx(ctx); fastReq != nil {
+		fastReq.Response.Header.Set(key, value)
+	}
+}
+
+// SetCookie sets cookie of the response
+func SetCookie(ctx context.Context, cookie *fasthttp.Cookie) {
+	if fastReq := requestCtx(ctx); fastReq != nil {
+		fastReq.Response.Header.SetCookie(cookie)
+	}
+}
+
+// SetStatus sets status code of successful response, the status of error
+// response is decided by the error returned
+func SetStatus(ctx context.Context, code int) {
+	if fastReq := requestCtx(ctx); fastReq != nil {
+		fastReq.SetStatusCode(code)
+	}
+}
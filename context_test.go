@@ -0,0 +1,93 @@
+package goapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/ottstack/goapi/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type ContextService struct {
+	info *RequestInfo
+}
+
+type createReq struct {
+	Name string `path:"name"`
+	Fail bool   `json:"fail"`
+}
+
+func (s *ContextService) Routes() map[string]string {
+	return map[string]string{"CreateItem": "PUT items/{name}"}
+}
+
+func (s *ContextService) CreateItem(ctx context.Context, req *createReq, rsp *optionsRsp) error {
+	s.info, _ = RequestFromContext(ctx)
+	SetResponseHeader(ctx, "Location", "/items/"+req.Name)
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+	cookie.SetKey("session")
+	cookie.SetValue("s1")
+	SetCookie(ctx, cookie)
+	SetStatus(ctx, 201)
+	if req.Fail {
+		return &ecode.APIError{Code: 409, Message: "Item exists"}
+	}
+	rsp.Message = "created " + req.Name
+	return nil
+}
+
+type ctxKey struct{}
+
+// wrapContext wraps the context like middlewares adding values
+func wrapContext(ctx context.Context, fastReq *fasthttp.RequestCtx, method middleware.MethodFunc, req, rsp interface{}) error {
+	return method(context.WithValue(ctx, ctxKey{}, "wrapped"), req, rsp)
+}
+
+func TestRequestContext(t *testing.T) {
+	s := NewServer()
+	s.Use(wrapContext)
+	sv := &ContextService{}
+	s.RegisterService(sv)
+
+	fastReq := doRequest(s, "PUT", "/api/items/a?debug=1", `{}`, "X-Request-Id", "r1", "Cookie", "theme=dark")
+	assert.Equal(t, 201, fastReq.Response.StatusCode())
+	assert.Equal(t, `{"message":"created a"}`, string(fastReq.Response.Body()))
+	assert.Equal(t, "/items/a", string(fastReq.Response.Header.Peek("Location")))
+	cookie := &fasthttp.Cookie{}
+	cookie.SetKey("session")
+	assert.True(t, fastReq.Response.Header.Cookie(cookie))
+	assert.Equal(t, "s1", string(cookie.Value()))
+
+	info := sv.info
+	if !assert.NotNil(t, info) {
+		return
+	}
+	assert.Equal(t, "PUT", info.Method)
+	assert.Equal(t, "/api/items/a", info.Path)
+	assert.Equal(t, DefaultListener, info.Listener)
+	assert.Equal(t, "CreateItem", info.MethodName)
+	assert.Equal(t, "r1", info.Header("X-Request-Id"))
+	assert.Equal(t, "dark", info.Cookie("theme"))
+	assert.Equal(t, "1", info.Query("debug"))
+	assert.True(t, info.RemoteIP().Equal(net.IPv4zero))
+	assert.Equal(t, fastReq, info.RequestCtx())
+
+	// status of error response is decided by the error
+	fastReq = doRequest(s, "PUT", "/api/items/a", `{"fail":true}`)
+	assert.Equal(t, 409, fastReq.Response.StatusCode())
+}
+
+func TestContextWithoutRequest(t *testing.T) {
+	ctx := context.Background()
+	_, ok := RequestFromContext(ctx)
+	assert.False(t, ok)
+	assert.Nil(t, PeerCertificate(ctx))
+	// no-op without request
+	SetResponseHeader(ctx, "X-Key", "value")
+	SetCookie(ctx, &fasthttp.Cookie{})
+	SetStatus(ctx, 201)
+}
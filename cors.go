@@ -0,0 +1,38 @@
+package goapi
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ottstack/goapi/pkg/middleware"
+	"github.com/valyala/fasthttp"
+)
+
+// UseCORS adds global CORS middleware, the allowed origins are also
+// accepted by websocket upgrade which is same-origin only by default.
+// It runs before the other middlewares no matter when they are added, so
+// preflight requests are not rejected by middlewares like authentication.
+func (s *Server) UseCORS(cfg middleware.CORSConfig) *Server {
+	policy := middleware.NewCORSPolicy(cfg)
+	if s.cors != nil {
+		// the former policy is always the first middleware
+		s.middlewares[0] = policy.Middleware
+	} else {
+		s.middlewares = append([]middleware.Middleware{policy.Middleware}, s.middlewares...)
+	}
+	s.cors = policy
+	return s
+}
+
+// checkOrigin checks Origin of websocket upgrade by CORS policy or same origin
+func (s *Server) checkOrigin(fastReq *fasthttp.RequestCtx) bool {
+	origin := string(fastReq.Request.Header.Peek("Origin"))
+	if origin == "" {
+		return true
+	}
+	if s.cors != nil && s.cors.AllowsOrigin(origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, string(fastReq.Host()))
+}
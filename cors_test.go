@@ -0,0 +1,109 @@
+package goapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/ottstack/goapi/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+// requireToken rejects requests without Authorization header
+func requireToken(ctx context.Context, fastReq *fasthttp.RequestCtx, method middleware.MethodFunc, req, rsp interface{}) error {
+	if len(fastReq.Request.Header.Peek("Authorization")) == 0 {
+		return &ecode.APIError{Code: 401, Message: "Unauthorized"}
+	}
+	return method(ctx, req, rsp)
+}
+
+type CORSService struct{}
+
+func (s *CORSService) MethodOptions() map[string]MethodOptions {
+	return map[string]MethodOptions{
+		"Hello": {Middlewares: []middleware.Middleware{middleware.CORS(middleware.CORSConfig{AllowOrigins: []string{"https://method.example.com"}})}},
+	}
+}
+
+func (s *CORSService) Hello(ctx context.Context, req *optionsReq, rsp *optionsRsp) error {
+	return nil
+}
+
+func (s *CORSService) Bye(ctx context.Context, req *optionsReq, rsp *optionsRsp) error {
+	return nil
+}
+
+func preflight(s *Server, path, origin string) *fasthttp.RequestCtx {
+	return doRequest(s, "OPTIONS", path, "", "Origin", origin, "Access-Control-Request-Method", "POST")
+}
+
+func TestUseCORSRunsFirst(t *testing.T) {
+	s := NewServer()
+	s.Use(requireToken)
+	s.UseCORS(middleware.CORSConfig{AllowOrigins: []string{"https://old.example.com"}})
+	s.UseCORS(middleware.CORSConfig{AllowOrigins: []string{"https://app.example.com"}})
+	s.RegisterService(&GroupService{})
+	assert.Equal(t, 2, len(s.middlewares))
+
+	fastReq := preflight(s, "/api/GroupService/Hello", "https://app.example.com")
+	assert.Equal(t, 204, fastReq.Response.StatusCode())
+	assert.Equal(t, "https://app.example.com", string(fastReq.Response.Header.Peek("Access-Control-Allow-Origin")))
+	assert.Equal(t, 403, preflight(s, "/api/GroupService/Hello", "https://old.example.com").Response.StatusCode())
+
+	// actual request is still authenticated
+	fastReq = doRequest(s, "POST", "/api/GroupService/Hello", "{}", "Origin", "https://app.example.com")
+	assert.Equal(t, 401, fastReq.Response.StatusCode())
+	assert.Equal(t, "https://app.example.com", string(fastReq.Response.Header.Peek("Access-Control-Allow-Origin")))
+}
+
+func TestPreflightOfRoute(t *testing.T) {
+	s := NewServer()
+	s.With(middleware.CORS(middleware.CORSConfig{AllowOrigins: []string{"https://group.example.com"}})).RegisterService(&GroupService{})
+	s.RegisterService(&CORSService{})
+
+	fastReq := preflight(s, "/api/GroupService/Hello", "https://group.example.com")
+	assert.Equal(t, 204, fastReq.Response.StatusCode())
+	assert.Equal(t, "https://group.example.com", string(fastReq.Response.Header.Peek("Access-Control-Allow-Origin")))
+	assert.Equal(t, "OPTIONS, POST", string(fastReq.Response.Header.Peek("Allow")))
+
+	fastReq = preflight(s, "/api/CORSService/Hello", "https://method.example.com")
+	assert.Equal(t, 204, fastReq.Response.StatusCode())
+	assert.Equal(t, "https://method.example.com", string(fastReq.Response.Header.Peek("Access-Control-Allow-Origin")))
+	assert.Equal(t, 403, preflight(s, "/api/CORSService/Hello", "https://group.example.com").Response.StatusCode())
+
+	// routes without CORS don't allow any origin
+	fastReq = preflight(s, "/api/CORSService/Bye", "https://method.example.com")
+	assert.Equal(t, 204, fastReq.Response.StatusCode())
+	assert.Empty(t, fastReq.Response.Header.Peek("Access-Control-Allow-Origin"))
+
+	// the route of requested method decides
+	fastReq = doRequest(s, "OPTIONS", "/api/CORSService/Hello", "", "Origin", "https://method.example.com", "Access-Control-Request-Method", "PUT")
+	assert.Equal(t, 204, fastReq.Response.StatusCode())
+	assert.Empty(t, fastReq.Response.Header.Peek("Access-Control-Allow-Origin"))
+}
+
+func TestCORSOfErrors(t *testing.T) {
+	s := NewServer()
+	s.UseCORS(middleware.CORSConfig{AllowOrigins: []string{"https://app.example.com"}, ExposeHeaders: []string{"X-Request-Id"}})
+	s.Use(requireToken)
+	s.RegisterService(&GroupService{})
+
+	// errors written before the middlewares carry CORS headers of allowed origin
+	for code, fastReq := range map[int]*fasthttp.RequestCtx{
+		404: doRequest(s, "POST", "/api/GroupService/Missing", "{}", "Origin", "https://app.example.com"),
+		405: doRequest(s, "GET", "/api/GroupService/Hello", "", "Origin", "https://app.example.com"),
+		400: doRequest(s, "POST", "/api/GroupService/Hello", "{", "Origin", "https://app.example.com"),
+		401: doRequest(s, "POST", "/api/GroupService/Hello", "{}", "Origin", "https://app.example.com"),
+	} {
+		assert.Equal(t, code, fastReq.Response.StatusCode())
+		assert.Equal(t, "https://app.example.com", string(fastReq.Response.Header.Peek("Access-Control-Allow-Origin")), code)
+		assert.Equal(t, "X-Request-Id", string(fastReq.Response.Header.Peek("Access-Control-Expose-Headers")), code)
+		assert.Equal(t, 1, len(fastReq.Response.Header.PeekAll("Vary")), code)
+	}
+
+	fastReq := doRequest(s, "POST", "/api/GroupService/Missing", "{}", "Origin", "https://evil.example.com")
+	assert.Equal(t, 404, fastReq.Response.StatusCode())
+	assert.Empty(t, fastReq.Response.Header.Peek("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", string(fastReq.Response.Header.Peek("Vary")))
+}
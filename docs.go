@@ -0,0 +1,291 @@
+package goapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+)
+
+//go:generate go run ./internal/cmd/fetchui ui
+
+//go:embed ui
+var embeddedUI embed.FS
+
+// assets smaller than gzipMinSize are not compressed
+const gzipMinSize = 1024
+
+// DocsPage is a documentation UI page served under HomePath
+type DocsPage struct {
+	// Path relative to HomePath, empty for HomePath itself
+	Path string
+	// HTML of page, {{specURL}} is replaced with the URL of OpenAPI document and
+	// {{assetsURL}} with {HomePath}assets/. Empty HTML removes the page.
+	HTML string
+	// Files of UI bundle served under {HomePath}assets/
+	Assets fs.FS
+}
+
+// AddDocsPage serves documentation UI like Scalar or RapiDoc, the built-in
+// Swagger UI at HomePath and Redoc at {HomePath}doc are replaced by the same path
+func (s *Server) AddDocsPage(page DocsPage) *Server {
+	s.docs.add(s.swaggerPath, page)
+	return s
+}
+
+// addBuiltinDocs adds Swagger UI and Redoc of embedded bundles, or of CDN if cdn is
+// set. Redoc is only added if its bundle is embedded.
+func (s *Server) addBuiltinDocs(cdn bool) {
+	if cdn {
+		s.AddDocsPage(DocsPage{HTML: swaggerCDNHTML})
+		s.AddDocsPage(DocsPage{Path: "doc", HTML: redocCDNHTML})
+		return
+	}
+	assets, _ := fs.Sub(embeddedUI, "ui")
+	s.AddDocsPage(DocsPage{HTML: swaggerHTML, Assets: assets})
+	if _, err := fs.Stat(assets, "redoc/redoc.standalone.js"); err == nil {
+		s.AddDocsPage(DocsPage{Path: "doc", HTML: redocHTML, Assets: assets})
+	}
+}
+
+type docsAsset struct {
+	body        []byte
+	gzipped     []byte
+	etag        string
+	gzipETag    string // ETag of gzipped body, which is a different representation
+	contentType string
+}
+
+// docsUI serves documentation pages and their assets
+type docsUI struct {
+	pages  map[string][]byte
+	assets []fs.FS
+
+	mu    sync.Mutex
+	cache map[string]*docsAsset
+}
+
+func newDocsUI() *docsUI {
+	return &docsUI{pages: map[string][]byte{}, cache: map[string]*docsAsset{}}
+}
+
+func (d *docsUI) add(homePath string, page DocsPage) {
+	pagePath := homePath + strings.TrimPrefix(page.Path, "/")
+	if page.HTML == "" {
+		delete(d.pages, pagePath)
+		return
+	}
+	html := strings.NewReplacer(
+		"{{specURL}}", homePath+"api.json",
+		"{{assetsURL}}", homePath+"assets/",
+	).Replace(page.HTML)
+	d.pages[pagePath] = []byte(html)
+	if page.Assets != nil {
+		// assets added later take priority
+		d.assets = append([]fs.FS{page.Assets}, d.assets...)
+	}
+}
+
+// load returns asset of name from the bundles, nil if not found
+func (d *docsUI) load(name string) *docsAsset {
+	if !fs.ValidPath(name) {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if a, ok := d.cache[name]; ok {
+		return a
+	}
+	for _, assets := range d.assets {
+		body, err := fs.ReadFile(assets, name)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(body)
+		a := &docsAsset{
+			body:        body,
+			etag:        `"` + hex.EncodeToString(sum[:8]) + `"`,
+			contentType: mime.TypeByExtension(path.Ext(name)),
+		}
+		if a.contentType == "" {
+			a.contentType = "application/octet-stream"
+		}
+		if len(body) >= gzipMinSize && compressible(a.contentType) {
+			var buf bytes.Buffer
+			zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+			zw.Write(body)
+			zw.Close()
+			a.gzipped = buf.Bytes()
+			a.gzipETag = strings.TrimSuffix(a.etag, `"`) + `-gz"`
+		}
+		d.cache[name] = a
+		return a
+	}
+	return nil
+}
+
+func compressible(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "javascript") ||
+		strings.Contains(contentType, "json") ||
+		strings.Contains(contentType, "svg")
+}
+
+// serveAsset writes asset with validator and compression, returns false if not found
+func (d *docsUI) serveAsset(name string, fastReq *fasthttp.RequestCtx) bool {
+	a := d.load(name)
+	if a == nil {
+		return false
+	}
+	body, etag := a.body, a.etag
+	gzipped := a.gzipped != nil && fastReq.Request.Header.HasAcceptEncoding("gzip")
+	if gzipped {
+		body, etag = a.gzipped, a.gzipETag
+	}
+	header := &fastReq.Response.Header
+	header.Set("Cache-Control", "public, max-age=86400")
+	header.Set("ETag", etag)
+	header.Set("Vary", "Accept-Encoding")
+	if matchETag(string(fastReq.Request.Header.Peek("If-None-Match")), etag) {
+		fastReq.SetStatusCode(fasthttp.StatusNotModified)
+		return true
+	}
+	header.SetContentType(a.contentType)
+	if gzipped {
+		header.Set("Content-Encoding", "gzip")
+	}
+	fastReq.SetBody(body)
+	return true
+}
+
+// matchETag reports whether If-None-Match header lists etag by weak comparison
+func matchETag(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+var swaggerHTML = `<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta charset="utf-8" />
+    <meta name="viewport" content="width=device-width, initial-scale=1" />
+    <meta
+      name="description"
+      content="SwaggerUI"
+    />
+    <title>SwaggerUI</title>
+    <link rel="stylesheet" href="{{assetsURL}}swagger-ui/swagger-ui.css" />
+  </head>
+  <body>
+  <div id="swagger-ui"></div>
+  <script src="{{assetsURL}}swagger-ui/swagger-ui-bundle.js"></script>
+  <script src="{{assetsURL}}swagger-ui/swagger-ui-standalone-preset.js"></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({
+        url: '{{specURL}}',
+        dom_id: '#swagger-ui',
+        presets: [
+          SwaggerUIBundle.presets.apis,
+          SwaggerUIStandalonePreset
+        ],
+        layout: "StandaloneLayout",
+      });
+    };
+  </script>
+  </body>
+</html>`
+
+var redocHTML = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>API Document</title>
+    <!-- needed for adaptive design -->
+    <meta charset="utf-8"/>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+
+    <!--
+    Redoc doesn't change outer page styles
+    -->
+    <style>
+      body {
+        margin: 0;
+        padding: 0;
+      }
+    </style>
+  </head>
+  <body>
+    <redoc spec-url='{{specURL}}'></redoc>
+    <script src="{{assetsURL}}redoc/redoc.standalone.js"> </script>
+  </body>
+</html>`
+
+var swaggerCDNHTML = `<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta charset="utf-8" />
+    <meta name="viewport" content="width=device-width, initial-scale=1" />
+    <meta
+      name="description"
+      content="SwaggerUI"
+    />
+    <title>SwaggerUI</title>
+    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@4.15.5/swagger-ui.css" />
+  </head>
+  <body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@4.15.5/swagger-ui-bundle.js" crossorigin></script>
+  <script src="https://unpkg.com/swagger-ui-dist@4.15.5/swagger-ui-standalone-preset.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({
+        url: '{{specURL}}',
+        dom_id: '#swagger-ui',
+        presets: [
+          SwaggerUIBundle.presets.apis,
+          SwaggerUIStandalonePreset
+        ],
+        layout: "StandaloneLayout",
+      });
+    };
+  </script>
+  </body>
+</html>`
+
+var redocCDNHTML = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>API Document</title>
+    <!-- needed for adaptive design -->
+    <meta charset="utf-8"/>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <link href="https://fonts.googleapis.com/css?family=Montserrat:300,400,700|Roboto:300,400,700" rel="stylesheet">
+
+    <!--
+    Redoc doesn't change outer page styles
+    -->
+    <style>
+      body {
+        margin: 0;
+        padding: 0;
+      }
+    </style>
+  </head>
+  <body>
+    <redoc spec-url='{{specURL}}'></redoc>
+    <script src="https://cdn.redoc.ly/redoc/v2.1.3/bundles/redoc.standalone.js"> </script>
+  </body>
+</html>`
@@ -0,0 +1,83 @@
+package goapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestDocsPage(t *testing.T) {
+	s := &Server{swaggerPath: "/docs/", docs: newDocsUI()}
+	s.addBuiltinDocs(true)
+	assert.Contains(t, string(s.docs.pages["/docs/"]), "url: '/docs/api.json'")
+
+	script := bytes.Repeat([]byte("console.log('scalar');\n"), 100)
+	s.AddDocsPage(DocsPage{
+		Path:   "scalar",
+		HTML:   `<script id="api-reference" data-url="{{specURL}}"></script><script src="{{assetsURL}}scalar/standalone.js"></script>`,
+		Assets: fstest.MapFS{"scalar/standalone.js": {Data: script}},
+	})
+	s.AddDocsPage(DocsPage{Path: "doc"})
+	assert.Nil(t, s.docs.pages["/docs/doc"])
+
+	fastReq := &fasthttp.RequestCtx{}
+	assert.True(t, s.serveDocs("/docs/scalar", fastReq))
+	assert.Equal(t, `<script id="api-reference" data-url="/docs/api.json"></script><script src="/docs/assets/scalar/standalone.js"></script>`, string(fastReq.Response.Body()))
+
+	fastReq = &fasthttp.RequestCtx{}
+	fastReq.Request.Header.Set("Accept-Encoding", "gzip")
+	assert.True(t, s.serveDocs("/docs/assets/scalar/standalone.js", fastReq))
+	assert.Equal(t, "gzip", string(fastReq.Response.Header.Peek("Content-Encoding")))
+	zr, err := gzip.NewReader(bytes.NewReader(fastReq.Response.Body()))
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(zr)
+	assert.Equal(t, script, body)
+	gzipETag := string(fastReq.Response.Header.Peek("ETag"))
+	assert.Regexp(t, `^"[0-9a-f]+-gz"$`, gzipETag)
+
+	fastReq = &fasthttp.RequestCtx{}
+	assert.True(t, s.serveDocs("/docs/assets/scalar/standalone.js", fastReq))
+	assert.Equal(t, script, fastReq.Response.Body())
+	etag := string(fastReq.Response.Header.Peek("ETag"))
+	assert.Regexp(t, `^"[0-9a-f]+"$`, etag)
+
+	fastReq = &fasthttp.RequestCtx{}
+	fastReq.Request.Header.Set("If-None-Match", etag)
+	assert.True(t, s.serveDocs("/docs/assets/scalar/standalone.js", fastReq))
+	assert.Equal(t, fasthttp.StatusNotModified, fastReq.Response.StatusCode())
+
+	// the identity body validated by cache doesn't match the gzipped one
+	fastReq = &fasthttp.RequestCtx{}
+	fastReq.Request.Header.Set("Accept-Encoding", "gzip")
+	fastReq.Request.Header.Set("If-None-Match", etag)
+	assert.True(t, s.serveDocs("/docs/assets/scalar/standalone.js", fastReq))
+	assert.Equal(t, fasthttp.StatusOK, fastReq.Response.StatusCode())
+	fastReq = &fasthttp.RequestCtx{}
+	fastReq.Request.Header.Set("Accept-Encoding", "gzip")
+	fastReq.Request.Header.Set("If-None-Match", etag+", W/"+gzipETag)
+	assert.True(t, s.serveDocs("/docs/assets/scalar/standalone.js", fastReq))
+	assert.Equal(t, fasthttp.StatusNotModified, fastReq.Response.StatusCode())
+
+	assert.False(t, s.serveDocs("/docs/assets/../serve.go", &fasthttp.RequestCtx{}))
+	assert.False(t, s.serveDocs("/docs/assets/scalar/missing.js", &fasthttp.RequestCtx{}))
+}
+
+func TestEmbeddedDocs(t *testing.T) {
+	s := &Server{swaggerPath: "/api/", docs: newDocsUI()}
+	s.addBuiltinDocs(false)
+	page := string(s.docs.pages["/api/"])
+	assert.Contains(t, page, `src="/api/assets/swagger-ui/swagger-ui-bundle.js"`)
+	assert.NotContains(t, page, "unpkg.com")
+
+	for _, name := range []string{"swagger-ui.css", "swagger-ui-bundle.js", "swagger-ui-standalone-preset.js"} {
+		fastReq := &fasthttp.RequestCtx{}
+		assert.True(t, s.serveDocs("/api/assets/swagger-ui/"+name, fastReq), name)
+		assert.Equal(t, fasthttp.StatusOK, fastReq.Response.StatusCode(), name)
+		assert.NotEmpty(t, fastReq.Response.Body(), name)
+	}
+}
@@ -0,0 +1,291 @@
+package goapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-errors/errors"
+	"github.com/ottstack/goapi/pkg/ecode"
+)
+
+// Enum can be implemented by named string or integer types to list their
+// allowed values, they are documented as enum of schema and other values of
+// request are rejected with 400 error. The zero value is allowed as unset
+// unless it's required by `validate` tag. Registering services fails if a value
+// is nil or can't be converted to the type.
+//
+//	func (Status) Enum() []goapi.EnumValue {
+//		return []goapi.EnumValue{{"StatusActive", StatusActive}, {"StatusInactive", StatusInactive}}
+//	}
+type Enum interface {
+	Enum() []EnumValue
+}
+
+// EnumValue is an allowed value of enum type and the name of its constant
+type EnumValue struct {
+	Name  string
+	Value interface{}
+}
+
+var enumType = reflect.TypeOf((*Enum)(nil)).Elem()
+
+var enums = struct {
+	sync.RWMutex
+	m      map[reflect.Type][]EnumValue
+	nested map[reflect.Type]bool
+}{m: map[reflect.Type][]EnumValue{}, nested: map[reflect.Type]bool{}}
+
+// RegisterEnum sets allowed values of the type of v, it's used for the types
+// of other packages without Enum method. It's also called by the file
+// generated by cmd/goapi-doc for enum types. It panics if a value is nil or
+// can't be converted to the type.
+func RegisterEnum(v interface{}, values ...EnumValue) {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	enums.Lock()
+	defer enums.Unlock()
+	converted, err := convertEnum(t, values)
+	if err != nil {
+		panic(err)
+	}
+	enums.m[t] = converted
+	enums.nested = map[reflect.Type]bool{}
+}
+
+// convertEnum converts values to type t, so they can be compared with field values
+func convertEnum(t reflect.Type, values []EnumValue) ([]EnumValue, error) {
+	converted := make([]EnumValue, 0, len(values))
+	for _, ev := range values {
+		if ev.Value == nil {
+			return nil, errors.Errorf("value of enum %s of %s is nil", ev.Name, t)
+		}
+		v := reflect.ValueOf(ev.Value)
+		if v.Type() != t {
+			if !canConvert(v, t) {
+				return nil, errors.Errorf("value %v of enum %s can't be converted from %s to %s", ev.Value, ev.Name, v.Type(), t)
+			}
+			ev.Value = v.Convert(t).Interface()
+		}
+		converted = append(converted, ev)
+	}
+	return converted, nil
+}
+
+// canConvert returns whether v can be converted to t and back without loss
+func canConvert(v reflect.Value, t reflect.Type) bool {
+	if !v.Type().ConvertibleTo(t) || !t.ConvertibleTo(v.Type()) || !v.Type().Comparable() {
+		return false
+	}
+	return v.Convert(t).Convert(v.Type()).Interface() == v.Interface()
+}
+
+// lookupEnum returns allowed values of t by registration or Enum method, the error
+// is returned if values of Enum method can't be converted to t
+func lookupEnum(t reflect.Type) ([]EnumValue, bool, error) {
+	enums.RLock()
+	values, ok := enums.m[t]
+	enums.RUnlock()
+	if ok {
+		return values, true, nil
+	}
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface || !t.Implements(enumType) {
+		return nil, false, nil
+	}
+	values, err := convertEnum(t, reflect.Zero(t).Interface().(Enum).Enum())
+	if err != nil {
+		return nil, false, err
+	}
+	enums.Lock()
+	defer enums.Unlock()
+	enums.m[t] = values
+	return values, true, nil
+}
+
+// enumSchema returns schema of enum type t with values as JSON encoded, nil if t is not enum
+func enumSchema(t reflect.Type) (*openapi3.Schema, error) {
+	values, ok, err := lookupEnum(t)
+	if !ok {
+		return nil, err
+	}
+	schema := &openapi3.Schema{}
+	var names []interface{}
+	for _, ev := range values {
+		var v interface{}
+		bs, err := json.Marshal(ev.Value)
+		if err != nil || json.Unmarshal(bs, &v) != nil {
+			continue
+		}
+		switch v.(type) {
+		case string:
+			schema.Type = "string"
+		case float64:
+			schema.Type = "number"
+			if t.Kind() != reflect.Float32 && t.Kind() != reflect.Float64 {
+				schema.Type = "integer"
+			}
+		}
+		schema.Enum = append(schema.Enum, v)
+		names = append(names, ev.Name)
+	}
+	if len(names) > 0 && names[0] != "" {
+		schema.Extensions = map[string]interface{}{"x-enum-varnames": names}
+	}
+	return schema, nil
+}
+
+// hasEnum returns whether values of t contain enum types to be checked
+func hasEnum(t reflect.Type) bool {
+	has, _ := findEnum(t, map[reflect.Type]bool{})
+	return has
+}
+
+// findEnum returns whether t contains enum types, the result is inexact and not
+// cached if it depends on recursive types being visited
+func findEnum(t reflect.Type, visiting map[reflect.Type]bool) (bool, bool) {
+	enums.RLock()
+	has, ok := enums.nested[t]
+	enums.RUnlock()
+	if ok {
+		return has, true
+	}
+	if visiting[t] {
+		return false, false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	exact := true
+	check := func(sub reflect.Type) {
+		if !has {
+			h, e := findEnum(sub, visiting)
+			has, exact = h, exact && e
+		}
+	}
+	// types of invalid enum values fail the registration, they are not checked
+	if _, ok, _ := lookupEnum(t); ok {
+		has = true
+	} else {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			check(t.Elem())
+		case reflect.Map:
+			check(t.Key())
+			check(t.Elem())
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				if t.Field(i).IsExported() {
+					check(t.Field(i).Type)
+				}
+			}
+		}
+	}
+	// t itself is the only type being visited, so the result is final
+	if has || exact || len(visiting) == 1 {
+		enums.Lock()
+		enums.nested[t] = has
+		enums.Unlock()
+		return has, true
+	}
+	return has, false
+}
+
+// checkEnums returns 400 error if any enum value of request is not allowed
+func checkEnums(req interface{}) error {
+	if req == nil {
+		return nil
+	}
+	v := reflect.ValueOf(req)
+	if !hasEnum(v.Type()) {
+		return nil
+	}
+	apiErr := &ecode.APIError{Code: 400}
+	var messages []string
+	walkEnums(v, "", func(path string, v reflect.Value, values []EnumValue) {
+		var allowed []string
+		for _, ev := range values {
+			if v.Interface() == ev.Value {
+				return
+			}
+			allowed = append(allowed, fmt.Sprint(ev.Value))
+		}
+		if v.IsZero() {
+			return
+		}
+		msg := fmt.Sprintf("%s must be one of [%s]", path, strings.Join(allowed, " "))
+		messages = append(messages, msg)
+		apiErr.Violations = append(apiErr.Violations, ecode.FieldViolation{
+			Field:       path,
+			Description: msg,
+			Rule:        "enum",
+			Param:       strings.Join(allowed, " "),
+		})
+	})
+	if len(messages) == 0 {
+		return nil
+	}
+	apiErr.Message = strings.Join(messages, "; ")
+	return apiErr
+}
+
+// walkEnums calls fn with the enum values in v and their paths like "items[0].status"
+func walkEnums(v reflect.Value, path string, fn func(string, reflect.Value, []EnumValue)) {
+	if !hasEnum(v.Type()) {
+		return
+	}
+	if values, ok, _ := lookupEnum(v.Type()); ok {
+		fn(path, v, values)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			walkEnums(v.Elem(), path, fn)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkEnums(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprintf("%s[%v]", path, iter.Key())
+			walkEnums(iter.Key(), key, fn)
+			walkEnums(iter.Value(), key, fn)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := jsonName(field)
+			if name == "-" {
+				continue
+			}
+			if path != "" && !field.Anonymous {
+				name = path + "." + name
+			} else if field.Anonymous {
+				name = path
+			}
+			walkEnums(v.Field(i), name, fn)
+		}
+	}
+}
+
+// jsonName returns parameter name of bound field or json name of body field
+func jsonName(field reflect.StructField) string {
+	if in, name := boundTag(field); in != "" {
+		return name
+	}
+	name, _, _ := strings.Cut(field.Tag.Get(filedNameTag), ",")
+	if name == "" {
+		name = strings.ToLower(field.Name[:1]) + field.Name[1:]
+	}
+	return name
+}
@@ -0,0 +1,113 @@
+package goapi
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/stretchr/testify/assert"
+)
+
+type orderStatus string
+
+const (
+	orderPending orderStatus = "pending"
+	orderShipped orderStatus = "shipped"
+)
+
+func (orderStatus) Enum() []EnumValue {
+	return []EnumValue{{"OrderPending", orderPending}, {"OrderShipped", orderShipped}}
+}
+
+type priority int
+
+type orderItem struct {
+	Priority priority `json:"priority"`
+}
+
+type orderReq struct {
+	Status orderStatus   `query:"status"`
+	Items  []orderItem   `json:"items"`
+	Next   *orderReq     `json:"next"`
+	Tags   []orderStatus `json:"tags"`
+}
+
+type EnumService struct{}
+
+func (s *EnumService) Search(ctx context.Context, req *orderReq, rsp *optionsRsp) error {
+	return nil
+}
+
+func TestEnum(t *testing.T) {
+	RegisterEnum(priority(0), EnumValue{"PriorityLow", 1}, EnumValue{"PriorityHigh", 2})
+
+	s := NewServer()
+	s.RegisterService(&EnumService{})
+	status := s.api.model.Paths["/api/EnumService/Search"].Post.Parameters.GetByInAndName("query", "status").Schema.Value
+	assert.Equal(t, "string", status.Type)
+	assert.Equal(t, []interface{}{"pending", "shipped"}, status.Enum)
+	assert.Equal(t, []interface{}{"OrderPending", "OrderShipped"}, status.Extensions["x-enum-varnames"])
+	item := s.api.model.Components.Schemas["EnumServiceorderItem"].Value
+	assert.Equal(t, "integer", item.Properties["priority"].Value.Type)
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, item.Properties["priority"].Value.Enum)
+
+	fastReq := doRequest(s, "POST", "/api/EnumService/Search?status=shipped", `{"items":[{"priority":2},{}],"tags":["pending"]}`)
+	assert.Equal(t, 200, fastReq.Response.StatusCode())
+
+	fastReq = doRequest(s, "POST", "/api/EnumService/Search?status=lost", `{"next":{"items":[{"priority":3}]}}`)
+	assert.Equal(t, 400, fastReq.Response.StatusCode())
+	apiErr := &ecode.APIError{}
+	assert.Nil(t, json.Unmarshal(fastReq.Response.Body(), apiErr))
+	assert.Equal(t, "status must be one of [pending shipped]; next.items[0].priority must be one of [1 2]", apiErr.Message)
+	assert.Equal(t, "next.items[0].priority", apiErr.Violations[1].Field)
+}
+
+type badStatus string
+
+func (badStatus) Enum() []EnumValue {
+	return []EnumValue{{"BadNil", nil}}
+}
+
+func TestRegisterEnumInvalid(t *testing.T) {
+	type color string
+	assert.PanicsWithError(t, "value of enum Red of goapi.color is nil", func() {
+		RegisterEnum(color(""), EnumValue{"Red", nil})
+	})
+	// integer isn't converted to string as rune
+	assert.PanicsWithError(t, "value 65 of enum Red can't be converted from int to goapi.color", func() {
+		RegisterEnum(color(""), EnumValue{"Red", 65})
+	})
+	assert.PanicsWithError(t, "value 1.5 of enum PriorityLow can't be converted from float64 to goapi.priority", func() {
+		RegisterEnum((*priority)(nil), EnumValue{"PriorityLow", 1.5})
+	})
+	assert.PanicsWithError(t, "value 300 of enum PriorityLow can't be converted from int to int8", func() {
+		RegisterEnum(int8(0), EnumValue{"PriorityLow", 300})
+	})
+	_, ok, err := lookupEnum(reflect.TypeOf(color("")))
+	assert.False(t, ok)
+	assert.NoError(t, err)
+}
+
+type badStatusReq struct {
+	Status badStatus `query:"status"`
+}
+
+type BadEnumService struct{}
+
+func (s *BadEnumService) Search(ctx context.Context, req *badStatusReq, rsp *optionsRsp) error {
+	return nil
+}
+
+func TestEnumMethodInvalid(t *testing.T) {
+	_, ok, err := lookupEnum(reflect.TypeOf(badStatus("")))
+	assert.False(t, ok)
+	assert.EqualError(t, err, "value of enum BadNil of goapi.badStatus is nil")
+
+	// registration fails instead of panicking
+	s := NewServer()
+	err = s.parse(s.root, []interface{}{&BadEnumService{}})
+	assert.ErrorContains(t, err, "value of enum BadNil of goapi.badStatus is nil")
+	assert.NoError(t, checkEnums(&badStatusReq{Status: "lost"}))
+}
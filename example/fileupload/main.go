@@ -13,7 +13,7 @@ func main() {
 	srv.Use(middleware.Recover).Use(middleware.Validator)
 
 	// curl -F file=@main.go http://127.0.0.1:8081/api/upload
-	srv.RegisterHTTP("/api/upload", func(ctx *fasthttp.RequestCtx) {
+	srv.RegisterHTTP("POST /api/upload", func(ctx *fasthttp.RequestCtx) {
 		fh, err := ctx.FormFile("file")
 		if err != nil {
 			ctx.Response.SetStatusCode(400)
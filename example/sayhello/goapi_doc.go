@@ -0,0 +1,19 @@
+// Code generated by goapi-doc. DO NOT EDIT.
+
+package main
+
+import "github.com/ottstack/goapi"
+
+func init() {
+	goapi.RegisterDoc((*GetGreetingRequest)(nil), "", map[string]string{
+		"Times": "Times of greeting",
+	})
+	goapi.RegisterDoc((*HelloService)(nil), "HelloService greets people", map[string]string{
+		"GetGreeting": "GetGreeting repeats greeting to the name in path.\n\nThe response can be cached for one minute.",
+		"SayHello":    "SayHello greets the name in body",
+	})
+	goapi.RegisterDoc((*SayHelloRequest)(nil), "SayHelloRequest is the request to say hello", nil)
+	goapi.RegisterDoc((*SayHelloResponse)(nil), "", map[string]string{
+		"Reply": "greeting to the name",
+	})
+}
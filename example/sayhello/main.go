@@ -1,8 +1,11 @@
+//go:generate go run github.com/ottstack/goapi/cmd/goapi-doc
+
 package main
 
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/ottstack/goapi"
 	"github.com/ottstack/goapi/pkg/middleware"
@@ -10,16 +13,41 @@ import (
 	"github.com/valyala/fasthttp"
 )
 
+// SayHelloRequest is the request to say hello
 type SayHelloRequest struct {
 	Name string `json:"name" validate:"required" comment:"Required Name"`
 }
 
 type SayHelloResponse struct {
-	Reply string `json:"reply"`
+	Reply string `json:"reply"` // greeting to the name
+}
+
+type GetGreetingRequest struct {
+	Name string `path:"name" comment:"Name in path"`
+	// Times of greeting
+	Times int    `path:"times"`
+	Punct string `query:"punct" comment:"Punctuation after name"`
 }
 
+// HelloService greets people
 type HelloService struct{}
 
+func (s *HelloService) Routes() map[string]string {
+	return map[string]string{
+		"GetGreeting": "GET greetings/{name}/{times}",
+	}
+}
+
+// GetGreeting repeats greeting to the name in path.
+//
+// The response can be cached for one minute.
+func (s *HelloService) GetGreeting(ctx context.Context, req *GetGreetingRequest, rsp *SayHelloResponse) error {
+	rsp.Reply = strings.Repeat("Hello "+req.Name+req.Punct+" ", req.Times)
+	goapi.SetResponseHeader(ctx, "Cache-Control", "max-age=60")
+	return nil
+}
+
+// SayHello greets the name in body
 func (s *HelloService) SayHello(ctx context.Context, req *SayHelloRequest, rsp *SayHelloResponse) error {
 	rsp.Reply = "Hello " + req.Name
 	return nil
@@ -49,6 +77,7 @@ func main() {
 	srv.Use(middleware.Recover).Use(middleware.Validator)
 
 	// curl '127.0.0.1:8081/api/HelloService/SayHello' -d '{"name": "alice"}'
+	// curl '127.0.0.1:8081/api/greetings/alice/2?punct=!'
 	// websocket: 127.0.0.1:8081/api/HelloService/StreamHello
 	srv.RegisterService(&HelloService{})
 
@@ -0,0 +1,73 @@
+package goapi
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// typeDoc is the doc comments of a type and its fields or methods
+type typeDoc struct {
+	doc     string
+	members map[string]string
+}
+
+var typeDocs = struct {
+	sync.RWMutex
+	m map[reflect.Type]*typeDoc
+}{m: map[reflect.Type]*typeDoc{}}
+
+// RegisterDoc registers doc comments of the type of v and its fields or methods
+// by name, it's called by the file generated by cmd/goapi-doc:
+//
+//	//go:generate go run github.com/ottstack/goapi/cmd/goapi-doc
+//
+// The doc of service and methods describe the tag and operations, the doc of
+// structs and fields describe the schemas. Field descriptions in `comment` tag
+// take priority.
+func RegisterDoc(v interface{}, doc string, members map[string]string) {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	typeDocs.Lock()
+	defer typeDocs.Unlock()
+	typeDocs.m[t] = &typeDoc{doc: doc, members: members}
+}
+
+// lookupDoc returns doc comment of type t, or of its field or method if member is not empty
+func lookupDoc(t reflect.Type, member string) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	typeDocs.RLock()
+	defer typeDocs.RUnlock()
+	d, ok := typeDocs.m[t]
+	if !ok {
+		return ""
+	}
+	if member == "" {
+		return d.doc
+	}
+	return d.members[member]
+}
+
+// fieldDescription returns description of field from `comment` tag or doc comment
+func fieldDescription(owner reflect.Type, field reflect.StructField) string {
+	if comment := field.Tag.Get("comment"); comment != "" {
+		return comment
+	}
+	return lookupDoc(owner, field.Name)
+}
+
+// splitDoc splits doc comment into summary of the first paragraph and the description,
+// description is empty if the doc has only one paragraph
+func splitDoc(doc string) (string, string) {
+	doc = strings.TrimSpace(doc)
+	first, rest, _ := strings.Cut(doc, "\n\n")
+	summary := strings.Join(strings.Fields(first), " ")
+	if strings.TrimSpace(rest) == "" {
+		return summary, ""
+	}
+	return summary, doc
+}
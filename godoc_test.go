@@ -0,0 +1,48 @@
+package goapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type docReq struct {
+	Name string `json:"name"`
+	Page int    `query:"page"`
+	Note string `json:"note" comment:"Note from tag"`
+}
+
+type DocService struct{}
+
+func (s *DocService) Search(ctx context.Context, req *docReq, rsp *optionsRsp) error {
+	return nil
+}
+
+func TestRegisterDoc(t *testing.T) {
+	RegisterDoc((*DocService)(nil), "DocService searches documents", map[string]string{
+		"Search": "Search finds documents\nby name.\n\nResults are paged.",
+	})
+	RegisterDoc((*docReq)(nil), "docReq is the query of documents", map[string]string{
+		"Name": "name of document",
+		"Page": "page number",
+		"Note": "note from doc",
+	})
+
+	s := NewServer()
+	s.RegisterService(&DocService{})
+	oper := s.api.model.Paths["/api/DocService/Search"].Post
+	assert.Equal(t, "Search finds documents by name.", oper.Summary)
+	assert.Equal(t, "Search finds documents\nby name.\n\nResults are paged.", oper.Description)
+	assert.Equal(t, "page number", oper.Parameters.GetByInAndName("query", "page").Description)
+	assert.Equal(t, "DocService searches documents", s.api.model.Tags.Get("DocService").Description)
+
+	schema := s.api.model.Components.Schemas["DocServicedocReq"].Value
+	assert.Equal(t, "docReq is the query of documents", schema.Description)
+	assert.Equal(t, "name of document", schema.Properties["name"].Value.Description)
+	assert.Equal(t, "Note from tag", schema.Properties["note"].Value.Description)
+
+	summary, description := splitDoc("One line only.\n")
+	assert.Equal(t, "One line only.", summary)
+	assert.Equal(t, "", description)
+}
@@ -0,0 +1,109 @@
+package goapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ottstack/goapi/pkg/middleware"
+	"github.com/valyala/fasthttp"
+)
+
+// Group registers services and http handlers under a path prefix.
+// Middlewares of group run after the global ones of server and
+// the ones of its parent groups.
+type Group struct {
+	server      *Server
+	parent      *Group
+	prefix      string
+	middlewares []middleware.Middleware
+	listeners   []string
+}
+
+// Group creates a group with prefix prepended to the paths registered in it
+func (s *Server) Group(prefix string, middlewares ...middleware.Middleware) *Group {
+	return s.root.Group(prefix, middlewares...)
+}
+
+// With creates a group without prefix, used to add middlewares for specified services
+//
+//	srv.With(auth).RegisterService(&AdminService{})
+func (s *Server) With(middlewares ...middleware.Middleware) *Group {
+	return s.root.With(middlewares...)
+}
+
+// Group creates a sub group with prefix appended to the prefix of g
+func (g *Group) Group(prefix string, middlewares ...middleware.Middleware) *Group {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return &Group{
+		server:      g.server,
+		parent:      g,
+		prefix:      g.prefix + prefix,
+		middlewares: middlewares,
+	}
+}
+
+// With creates a sub group with the same prefix of g
+func (g *Group) With(middlewares ...middleware.Middleware) *Group {
+	return g.Group("", middlewares...)
+}
+
+func (g *Group) Use(m middleware.Middleware) *Group {
+	g.middlewares = append(g.middlewares, m)
+	return g
+}
+
+func (g *Group) RegisterService(services ...interface{}) {
+	if err := g.server.parse(g, services); err != nil {
+		g.server.checkError(err)
+	}
+}
+
+// RegisterServiceWith registers service with middlewares only for its methods,
+// they run after the middlewares of g
+//
+//	srv.RegisterServiceWith(&AdminService{}, auth)
+func (g *Group) RegisterServiceWith(service interface{}, middlewares ...middleware.Middleware) {
+	g.With(middlewares...).RegisterService(service)
+}
+
+// RegisterHTTP registers raw fasthttp handler on path. The path can be
+// prefixed with a method like "GET /static/*filepath" to serve that method
+// only, path parameters are stored in the user values of request.
+func (g *Group) RegisterHTTP(path string, function func(*fasthttp.RequestCtx)) {
+	verb := anyVerb
+	if strings.Contains(path, " ") {
+		var err error
+		if verb, path, err = parseRoute(path); err != nil {
+			g.server.checkError(err)
+		}
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if _, err := g.server.router.add(verb, g.prefix+path, &handler{raw: function, group: g}); err != nil {
+		g.server.checkError(err)
+	}
+}
+
+// chain wraps method with middlewares of server and groups from g up to root
+func (g *Group) chain(fastReq *fasthttp.RequestCtx, method middleware.MethodFunc) middleware.MethodFunc {
+	for ; g != nil; g = g.parent {
+		method = chainMiddlewares(fastReq, g.middlewares, method)
+	}
+	return method
+}
+
+func chainMiddlewares(fastReq *fasthttp.RequestCtx, middlewares []middleware.Middleware, method middleware.MethodFunc) middleware.MethodFunc {
+	for i := range middlewares {
+		mware := middlewares[len(middlewares)-i-1]
+		method = func(mm middleware.MethodFunc) middleware.MethodFunc {
+			return func(ctx context.Context, req, rsp interface{}) error {
+				return mware(ctx, fastReq, mm, req, rsp)
+			}
+		}(method)
+	}
+	return method
+}
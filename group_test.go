@@ -0,0 +1,65 @@
+package goapi
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ottstack/goapi/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type GroupService struct{}
+
+func (s *GroupService) Hello(ctx context.Context, req *optionsReq, rsp *optionsRsp) error {
+	rsp.Message = "hello " + req.Name
+	return nil
+}
+
+// trace appends name to X-Trace header of response, to check the order of middlewares
+func trace(name string) middleware.Middleware {
+	return func(ctx context.Context, fastReq *fasthttp.RequestCtx, method middleware.MethodFunc, req, rsp interface{}) error {
+		fastReq.Response.Header.Add("X-Trace", name)
+		return method(ctx, req, rsp)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	s := NewServer()
+	s.Use(trace("global"))
+	admin := s.Group("admin/", trace("admin"))
+	admin.RegisterService(&GroupService{})
+	v1 := admin.Group("/v1", trace("v1"))
+	v1.RegisterServiceWith(&OptionsService{}, trace("service"))
+	v1.RegisterHTTP("GET /ping", func(fastReq *fasthttp.RequestCtx) {
+		fastReq.WriteString("pong")
+	})
+	s.RegisterHTTP("GET /health", func(fastReq *fasthttp.RequestCtx) {})
+
+	traces := func(fastReq *fasthttp.RequestCtx) string {
+		var names []string
+		for _, v := range fastReq.Response.Header.PeekAll("X-Trace") {
+			names = append(names, string(v))
+		}
+		return strings.Join(names, ",")
+	}
+
+	fastReq := doRequest(s, "POST", "/admin/api/GroupService/Hello", `{"name":"a"}`)
+	assert.Equal(t, 200, fastReq.Response.StatusCode())
+	assert.Equal(t, "global,admin", traces(fastReq))
+	assert.Equal(t, 404, doRequest(s, "POST", "/api/GroupService/Hello", `{}`).Response.StatusCode())
+
+	// per-method middlewares of OptionsService run last
+	fastReq = doRequest(s, "POST", "/admin/v1/api/OptionsService/Hello", `{"name":"a"}`)
+	assert.Equal(t, 200, fastReq.Response.StatusCode())
+	assert.Equal(t, "global,admin,v1,service", traces(fastReq))
+	assert.Equal(t, "Hello", string(fastReq.Response.Header.Peek("X-Method")))
+
+	fastReq = doRequest(s, "GET", "/admin/v1/ping", "")
+	assert.Equal(t, "pong", string(fastReq.Response.Body()))
+	assert.Equal(t, "global,admin,v1", traces(fastReq))
+
+	fastReq = doRequest(s, "GET", "/health", "")
+	assert.Equal(t, "global", traces(fastReq))
+}
@@ -0,0 +1,105 @@
+// Command fetchui downloads the documentation UI bundles embedded by goapi.
+//
+//	go generate github.com/ottstack/goapi
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type bundle struct {
+	url   string
+	dir   string
+	files []string
+}
+
+var bundles = []bundle{
+	{
+		url:   "https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-4.15.5.tgz",
+		dir:   "swagger-ui",
+		files: []string{"swagger-ui.css", "swagger-ui-bundle.js", "swagger-ui-standalone-preset.js", "LICENSE"},
+	},
+	{
+		url:   "https://registry.npmjs.org/redoc/-/redoc-2.1.3.tgz",
+		dir:   "redoc",
+		files: []string{"bundles/redoc.standalone.js", "LICENSE"},
+	},
+}
+
+func main() {
+	out := "ui"
+	if len(os.Args) > 1 {
+		out = os.Args[1]
+	}
+	for _, b := range bundles {
+		if err := fetch(b, filepath.Join(out, b.dir)); err != nil {
+			log.Fatalf("fetch %s: %v", b.url, err)
+		}
+		log.Printf("fetched %s into %s", b.url, filepath.Join(out, b.dir))
+	}
+}
+
+// fetch extracts the files of npm package tarball into dir without their directories
+func fetch(b bundle, dir string) error {
+	rsp, err := http.Get(b.url)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", rsp.StatusCode)
+	}
+	gz, err := gzip.NewReader(rsp.Body)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	wanted := map[string]bool{}
+	for _, f := range b.files {
+		wanted["package/"+f] = true
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !wanted[hdr.Name] {
+			continue
+		}
+		delete(wanted, hdr.Name)
+		name := filepath.Join(dir, filepath.Base(hdr.Name))
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	if len(wanted) > 0 {
+		var missing []string
+		for name := range wanted {
+			missing = append(missing, name)
+		}
+		return fmt.Errorf("files not found: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
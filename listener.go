@@ -0,0 +1,146 @@
+package goapi
+
+import (
+	"crypto/tls"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// DefaultListener is the name of listener on SERVE_ADDR
+const DefaultListener = "default"
+
+const unixPrefix = "unix:"
+
+type listener struct {
+	name   string
+	addr   string
+	ln     net.Listener
+	tls    bool
+	server *fasthttp.Server
+}
+
+// AddListener adds a listener named name on addr, the address in the form of
+// "unix:/path/to/socket" listens on unix domain socket.
+func (s *Server) AddListener(name, addr string) *Server {
+	return s.addListener(&listener{name: name, addr: addr})
+}
+
+// AddNetListener adds a listener named name serving on ln, TCP listener is
+// wrapped with TLS if SERVE_TLS_CERT is set
+func (s *Server) AddNetListener(name string, ln net.Listener) *Server {
+	return s.addListener(&listener{name: name, addr: ln.Addr().String(), ln: ln})
+}
+
+func (s *Server) addListener(l *listener) *Server {
+	for _, exist := range s.listeners {
+		if exist.name == l.name {
+			s.checkError(errors.Errorf("listener %s already exists", l.name))
+		}
+	}
+	s.listeners = append(s.listeners, l)
+	return s
+}
+
+// ExposeDocsOn serves the OpenAPI document and UI only on the named listeners,
+// Serve fails if any of them is not added
+func (s *Server) ExposeDocsOn(names ...string) *Server {
+	s.docsListeners = names
+	return s
+}
+
+// ExposeOn serves the routes registered in g only on the named listeners,
+// Serve fails if any of them is not added
+func (g *Group) ExposeOn(names ...string) *Group {
+	g.listeners = names
+	g.server.exposedGroups = append(g.server.exposedGroups, g)
+	return g
+}
+
+// exposedOn reports whether the routes of g are served on the named listener,
+// the nearest group setting listeners decides
+func (g *Group) exposedOn(name string) bool {
+	for ; g != nil; g = g.parent {
+		if g.listeners != nil {
+			return containsString(g.listeners, name)
+		}
+	}
+	return true
+}
+
+// checkExposure returns error if ExposeOn or ExposeDocsOn names a listener not added
+func (s *Server) checkExposure() error {
+	names := map[string]bool{}
+	for _, l := range s.listeners {
+		names[l.name] = true
+	}
+	for _, name := range s.docsListeners {
+		if !names[name] {
+			return errors.Errorf("listener %s of ExposeDocsOn is not added", name)
+		}
+	}
+	for _, g := range s.exposedGroups {
+		for _, name := range g.listeners {
+			if !names[name] {
+				return errors.Errorf("listener %s of ExposeOn in group %q is not added", name, g.prefix)
+			}
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// listen creates the listener if it is not provided, TLS is only enabled for TCP address
+func (l *listener) listen(certs *certReloader) error {
+	if l.ln == nil {
+		ln, err := newNetListener(l.addr)
+		if err != nil {
+			return err
+		}
+		l.ln = ln
+	}
+	if certs != nil && !l.tls && l.ln.Addr().Network() == "tcp" {
+		l.ln = tls.NewListener(l.ln, certs.tlsConfig())
+		l.tls = true
+	}
+	return nil
+}
+
+// newNetListener listens on TCP address or unix domain socket of addr
+func newNetListener(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+		// remove stale socket file left by the former process
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(path)
+		}
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp4", addr)
+}
+
+// url returns the address of path shown in log
+func (l *listener) url(path string) string {
+	if strings.HasPrefix(l.addr, unixPrefix) {
+		return l.addr + " " + path
+	}
+	showAddr := l.addr
+	addrInfo := strings.SplitN(l.addr, ":", 2)
+	if len(addrInfo) == 2 && (addrInfo[0] == "" || addrInfo[0] == "0" || addrInfo[0] == "0.0.0.0") {
+		showAddr = "localhost:" + addrInfo[1]
+	}
+	if l.tls {
+		return "https://" + showAddr + path
+	}
+	return "http://" + showAddr + path
+}
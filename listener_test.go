@@ -0,0 +1,123 @@
+package goapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestListenerExposure(t *testing.T) {
+	s := NewServer()
+	s.AddListener("admin", "unix:/tmp/admin.sock")
+	s.ExposeDocsOn("admin")
+	s.Group("/admin").ExposeOn("admin").RegisterService(&GroupService{})
+	s.RegisterService(&OptionsService{})
+
+	call := func(listener, method, path string) *fasthttp.RequestCtx {
+		fastReq := &fasthttp.RequestCtx{}
+		fastReq.Request.Header.SetMethod(method)
+		fastReq.Request.SetRequestURI(path)
+		s.serve(listener, fastReq)
+		return fastReq
+	}
+
+	// routes of other listeners are not found and never reveal their methods
+	for _, method := range []string{"POST", "GET", "OPTIONS"} {
+		fastReq := call(DefaultListener, method, "/admin/api/GroupService/Hello")
+		assert.Equal(t, 404, fastReq.Response.StatusCode(), method)
+		assert.Empty(t, fastReq.Response.Header.Peek("Allow"), method)
+	}
+	assert.Equal(t, 404, call(DefaultListener, "POST", "/admin/api/GroupService/Hello/").Response.StatusCode())
+	assert.Equal(t, 404, call(DefaultListener, "GET", "/api/api.json").Response.StatusCode())
+
+	assert.Equal(t, 200, call("admin", "POST", "/admin/api/GroupService/Hello").Response.StatusCode())
+	fastReq := call("admin", "GET", "/admin/api/GroupService/Hello")
+	assert.Equal(t, 405, fastReq.Response.StatusCode())
+	assert.Equal(t, "OPTIONS, POST", string(fastReq.Response.Header.Peek("Allow")))
+	fastReq = call("admin", "POST", "/admin/api/GroupService/Hello/")
+	assert.Equal(t, 308, fastReq.Response.StatusCode())
+	assert.Equal(t, 200, call("admin", "GET", "/api/api.json").Response.StatusCode())
+
+	// routes of root group are served on every listener
+	assert.Equal(t, 200, call(DefaultListener, "POST", "/api/OptionsService/Hello").Response.StatusCode())
+	assert.Equal(t, 200, call("admin", "POST", "/api/OptionsService/Hello").Response.StatusCode())
+}
+
+func TestCheckExposure(t *testing.T) {
+	s := NewServer()
+	s.AddListener("admin", "unix:/tmp/admin.sock")
+	s.Group("/admin").ExposeOn("admin")
+	s.ExposeDocsOn("admin", DefaultListener)
+	assert.NoError(t, s.checkExposure())
+
+	s.ExposeDocsOn("internal")
+	assert.EqualError(t, s.checkExposure(), "listener internal of ExposeDocsOn is not added")
+
+	s = NewServer()
+	s.Group("/admin").ExposeOn("admin")
+	assert.EqualError(t, s.checkExposure(), `listener admin of ExposeOn in group "/admin" is not added`)
+	assert.EqualError(t, s.ServeContext(context.Background()), `listener admin of ExposeOn in group "/admin" is not added`)
+}
+
+func TestListen(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil)
+	certFile, keyFile := newTestCert(t, "server", ca).writeFiles(t, dir, time.Now())
+	certs, err := newCertReloader(certFile, keyFile, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// stale socket file of the former process is removed
+	sock := filepath.Join(dir, "api.sock")
+	stale, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	unix := &listener{name: "unix", addr: unixPrefix + sock}
+	assert.NoError(t, unix.listen(certs))
+	defer unix.ln.Close()
+	assert.False(t, unix.tls)
+	assert.Equal(t, "unix:"+sock+" /api/", unix.url("/api/"))
+	info, err := os.Stat(sock)
+	assert.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSocket)
+
+	// provided TCP listener is served with TLS
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provided := &listener{name: "provided", addr: ln.Addr().String(), ln: ln}
+	assert.NoError(t, provided.listen(certs))
+	defer provided.ln.Close()
+	assert.True(t, provided.tls)
+	assert.Equal(t, "https://"+ln.Addr().String()+"/api/", provided.url("/api/"))
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	served, err := handshake(provided.ln, &tls.Config{RootCAs: roots})
+	assert.NoError(t, err)
+	assert.Equal(t, "server", served.Subject.CommonName)
+
+	plain := &listener{name: "plain", addr: "0.0.0.0:0"}
+	assert.NoError(t, plain.listen(nil))
+	defer plain.ln.Close()
+	assert.False(t, plain.tls)
+	assert.Equal(t, "http://localhost:0/api/", plain.url("/api/"))
+
+	s := NewServer()
+	s.AddListener("plain", "127.0.0.1:0")
+	s.AddNetListener("unix", unix.ln)
+	assert.Equal(t, []string{DefaultListener, "plain", "unix"}, []string{s.listeners[0].name, s.listeners[1].name, s.listeners[2].name})
+	assert.Equal(t, unix.ln, s.listeners[2].ln)
+}
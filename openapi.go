@@ -3,12 +3,16 @@ package goapi
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
+	"net/http"
 	"reflect"
 	"strings"
-	"unicode"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-errors/errors"
+	"github.com/ottstack/goapi/pkg/coder"
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/ottstack/goapi/pkg/middleware"
+	"google.golang.org/protobuf/proto"
 )
 
 const schemaPrefix = "#/components/schemas/"
@@ -16,15 +20,16 @@ const filedNameTag = "json"
 
 var API_JSON = ""
 
+var apiErrorType = reflect.TypeOf(&ecode.APIError{})
+var problemType = reflect.TypeOf(&ecode.Problem{})
+
 type openapi struct {
-	model       *openapi3.T
-	swaggerHTML []byte
-	docHTML     []byte
+	model *openapi3.T
 
 	namePkg map[string]string
 }
 
-func newOpenapi(path string) *openapi {
+func newOpenapi() *openapi {
 	o := &openapi{}
 	o.model = &openapi3.T{
 		OpenAPI: "3.0.3",
@@ -37,24 +42,18 @@ func newOpenapi(path string) *openapi {
 			Schemas: openapi3.Schemas{},
 		},
 	}
-	o.docHTML = []byte(fmt.Sprintf(docHTML, path))
-	o.swaggerHTML = []byte(fmt.Sprintf(swaggerHTML, path))
 	o.namePkg = map[string]string{}
 	return o
 }
 
-func (o *openapi) addMethod(info *methodInfo) {
-	rspContent := openapi3.Content{"application/json": {
-		Schema: &openapi3.SchemaRef{
-			Ref: schemaPrefix + info.serviceName + info.rspType.Name(),
-		},
-	},
-	}
+func (o *openapi) addMethod(info *methodInfo) error {
+	rspContent := newContent(schemaPrefix+info.serviceName+info.rspType.Name(), info.codecs.ContentTypes())
 
 	oper := &openapi3.Operation{
-		OperationID: info.serviceName + info.methodName,
+		OperationID: info.operationID(),
 		Tags:        info.tags,
 		Summary:     info.summary,
+		Description: info.description,
 		Responses: openapi3.Responses{
 			"200": &openapi3.ResponseRef{
 				Value: &openapi3.Response{
@@ -63,53 +62,102 @@ func (o *openapi) addMethod(info *methodInfo) {
 			},
 			"default": &openapi3.ResponseRef{
 				Value: &openapi3.Response{
-					Content: openapi3.Content{"application/json": {
-						Schema: &openapi3.SchemaRef{
-							Ref: schemaPrefix + "APIError",
-						},
-					},
-					},
+					Content: errorContent(),
 				},
 			},
 		},
 	}
 
-	oper.RequestBody = &openapi3.RequestBodyRef{
-		Value: &openapi3.RequestBody{
-			Content: openapi3.Content{"application/json": {
-				Schema: &openapi3.SchemaRef{
-					Ref: schemaPrefix + info.serviceName + info.reqType.Name(),
-				},
+	if len(info.options.Security) > 0 {
+		security := openapi3.NewSecurityRequirements()
+		for _, requirement := range info.options.Security {
+			name, scopes := middleware.ParseSecurity(requirement)
+			security.With(openapi3.NewSecurityRequirement().Authenticate(name, scopes...))
+		}
+		oper.Security = security
+	}
+	if len(info.options.Extensions) > 0 {
+		oper.Extensions = map[string]interface{}{}
+		for k, v := range info.options.Extensions {
+			oper.Extensions[k] = v
+		}
+	}
+
+	for _, f := range info.params {
+		schema, err := o.parseType(info.serviceName, f.field.Type)
+		if err != nil {
+			return errors.Errorf("parameter %s of %s: %v", f.name, info.operationID(), err)
+		}
+		validateTag := f.field.Tag.Get("validate")
+		param := &openapi3.Parameter{
+			In:          f.in,
+			Name:        f.name,
+			Required:    f.in == openapi3.ParameterInPath || isRequired(validateTag),
+			Description: fieldDescription(info.reqType, f.field),
+			Schema:      schema,
+		}
+		applyValidation(param.Schema, validateTag)
+		oper.AddParameter(param)
+	}
+
+	if hasRequestBody(info.verb) {
+		oper.RequestBody = &openapi3.RequestBodyRef{
+			Value: &openapi3.RequestBody{
+				Content: newContent(schemaPrefix+info.serviceName+info.reqType.Name(), info.codecs.ContentTypes()),
 			},
-			}},
+		}
 	}
 
-	if _, ok := o.model.Paths[info.path]; !ok {
-		o.model.Paths[info.path] = &openapi3.PathItem{}
+	path := docPath(info.path)
+	if _, ok := o.model.Paths[path]; !ok {
+		o.model.Paths[path] = &openapi3.PathItem{}
 	}
-	o.model.Paths[info.path].Post = oper
-	o.parseType(info.serviceName, info.reqType)
-	o.parseType(info.serviceName, info.rspType)
+	o.model.Paths[path].SetOperation(info.verb, oper)
+	for _, t := range []reflect.Type{info.reqType, info.rspType} {
+		if _, err := o.parseType(info.serviceName, t); err != nil {
+			return errors.Errorf("%s of %s: %v", t, info.operationID(), err)
+		}
+	}
+	return nil
 }
 
-func (o *openapi) checkSchemaExists(name string, st reflect.Type) bool {
-	pkg := st.PkgPath()
-	if vv, ok := o.namePkg[name]; ok {
-		if vv != pkg {
-			panic(fmt.Sprintf("%s is defined in multiple package: %s %s", name, pkg, vv))
-		}
-		return true
+// newContent returns the content of schema in every media type
+func newContent(ref string, contentTypes []string) openapi3.Content {
+	content := openapi3.Content{}
+	for _, ct := range contentTypes {
+		content[ct] = &openapi3.MediaType{Schema: &openapi3.SchemaRef{Ref: ref}}
 	}
-	o.namePkg[name] = pkg
-	return false
+	return content
 }
 
-func (o *openapi) getSwaggerHTML() []byte {
-	return o.swaggerHTML
+// errorContent returns the content of error in every media type and problem details
+func errorContent() openapi3.Content {
+	content := newContent(schemaPrefix+"APIError", coder.For(apiErrorType).ContentTypes())
+	content[ecode.ProblemContentType] = &openapi3.MediaType{Schema: &openapi3.SchemaRef{Ref: schemaPrefix + "Problem"}}
+	return content
+}
+
+func hasRequestBody(verb string) bool {
+	switch verb {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+		return false
+	}
+	return true
 }
 
-func (o *openapi) getDocHTML() []byte {
-	return o.docHTML
+func (o *openapi) checkSchemaExists(name string, st reflect.Type) (bool, error) {
+	return o.checkSchemaOwner(name, st.PkgPath())
+}
+
+func (o *openapi) checkSchemaOwner(name string, pkg string) (bool, error) {
+	if vv, ok := o.namePkg[name]; ok {
+		if vv != pkg {
+			return false, errors.Errorf("%s is defined in multiple package: %s %s", name, pkg, vv)
+		}
+		return true, nil
+	}
+	o.namePkg[name] = pkg
+	return false, nil
 }
 
 func (o *openapi) getOpenAPIV3() []byte {
@@ -124,182 +172,151 @@ func (o *openapi) getOpenAPIV3() []byte {
 	return prettyJSON.Bytes()
 }
 
-func (o *openapi) parseType(namespace string, rType reflect.Type) *openapi3.SchemaRef {
+func (o *openapi) parseType(namespace string, rType reflect.Type) (*openapi3.SchemaRef, error) {
 	elemType := rType
-	if elemType.Kind() == reflect.Ptr { // pointer to struct
-		elemType = rType.Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	schema, err := typeSchema(elemType)
+	if err != nil {
+		return nil, err
+	}
+	if schema != nil {
+		return &openapi3.SchemaRef{Value: schema}, nil
+	}
+
+	if elemType.Kind() == reflect.Struct && coder.IsProtoMessage(elemType) {
+		msg := reflect.New(elemType).Interface().(proto.Message)
+		return o.parseProtoMessage(namespace, msg.ProtoReflect().Descriptor())
+	}
+
+	// free-form value of interface{}
+	if elemType.Kind() == reflect.Interface && elemType.NumMethod() == 0 {
+		return &openapi3.SchemaRef{Value: &openapi3.Schema{}}, nil
 	}
 
-	var apiType string
-	var subType *openapi3.SchemaRef
-	var properties openapi3.Schemas
 	switch elemType.Kind() {
 	case reflect.String:
-		apiType = "string"
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		apiType = "integer"
+		return &openapi3.SchemaRef{Value: openapi3.NewStringSchema()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &openapi3.SchemaRef{Value: openapi3.NewIntegerSchema()}, nil
 	case reflect.Float32, reflect.Float64:
-		apiType = "number"
+		return &openapi3.SchemaRef{Value: openapi3.NewFloat64Schema()}, nil
 	case reflect.Bool:
-		apiType = "boolean"
+		return &openapi3.SchemaRef{Value: openapi3.NewBoolSchema()}, nil
 	case reflect.Map:
-		apiType = "object"
-		keyType := elemType.Key()
-		if keyType == nil || keyType.Kind() != reflect.String {
-			panic(fmt.Sprintf("map key type for %s should be string instand of %v", elemType.Name(), elemType.Kind()))
+		if !isMapKey(elemType.Key()) {
+			return nil, errors.Errorf("map key type of %s should be string, integer or encoding.TextMarshaler", elemType)
 		}
-		subType = o.parseType(namespace, elemType.Elem())
+		subType, err := o.parseType(namespace, elemType.Elem())
+		if err != nil {
+			return nil, err
+		}
+		hasValue := true
+		return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "object", AdditionalProperties: openapi3.AdditionalProperties{
+			Has:    &hasValue,
+			Schema: subType,
+		}}}, nil
 	case reflect.Array, reflect.Slice:
-		apiType = "array"
-		subType = o.parseType(namespace, elemType.Elem())
+		subType, err := o.parseType(namespace, elemType.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "array", Items: subType}}, nil
 	case reflect.Interface, reflect.Struct:
-		apiType = "object"
-		fullName := elemType.Name()
-		if strings.HasPrefix(fullName, namespace) {
-			fullName = namespace + elemType.Name()
+		if elemType.Name() == "" {
+			return nil, errors.Errorf("anonymous struct %s is unsupported in %s", elemType, namespace)
 		}
-		if !o.checkSchemaExists(fullName, elemType) {
-			properties = openapi3.Schemas{}
-			var requiredFields []string
-			if elemType.Kind() == reflect.Struct {
-				if elemType.Name() == "" {
-					panic(fmt.Sprintf("embed struct is unsupported in %s", namespace))
-				}
-				var fields []reflect.StructField
-				for i := 0; i < elemType.NumField(); i++ {
-					field := elemType.Field(i)
-					fieldType := field.Type
-					if fieldType.Kind() == reflect.Ptr {
-						fieldType = field.Type.Elem()
-					}
-					// inherited struct
-					if field.Anonymous && fieldType.Kind() == reflect.Struct {
-						for j := 0; j < field.Type.NumField(); j++ {
-							fields = append(fields, field.Type.Field(j))
-						}
-					} else {
-						fields = append(fields, field)
-					}
-				}
-				for _, field := range fields {
-					fieldType := field.Type
-					if fieldType.Kind() == reflect.Ptr {
-						fieldType = field.Type.Elem()
-					}
-
-					if !unicode.IsUpper(rune(field.Name[0])) {
-						continue
-					}
-
-					fieldTag := field.Tag.Get(filedNameTag)
-					if fieldTag == "-" {
-						continue
-					}
-					if fieldTag == "" {
-						fieldTag = field.Name
-						fieldTag = strings.ToLower(fieldTag[:1]) + fieldTag[1:]
-					}
-					if idx := strings.IndexRune(fieldTag, ','); idx >= 0 {
-						fieldTag = fieldTag[:idx]
-					}
-
-					fieldSchema := o.parseType(namespace, fieldType)
-					validateTag := field.Tag.Get("validate")
-					if strings.HasSuffix(validateTag, "required") || strings.Contains(validateTag, "required,") {
-						requiredFields = append(requiredFields, fieldTag)
-					}
-
-					if fieldSchema.Value != nil {
-						fieldSchema.Value.Description = field.Tag.Get("comment")
-					}
+		typeName := namespace + elemType.Name()
+		exists, err := o.checkSchemaExists(typeName, elemType)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			schema, err := o.parseStruct(namespace, elemType)
+			if err != nil {
+				return nil, err
+			}
+			o.model.Components.Schemas[typeName] = &openapi3.SchemaRef{Value: schema}
+		}
+		return &openapi3.SchemaRef{Ref: schemaPrefix + typeName}, nil
+	}
+	return nil, errors.Errorf("unsupported type %s in %s", rType, namespace)
+}
 
-					properties[fieldTag] = fieldSchema
+// parseStruct returns object schema of struct or interface type, fields of embedded struct are inherited
+func (o *openapi) parseStruct(namespace string, elemType reflect.Type) (*openapi3.Schema, error) {
+	schema := &openapi3.Schema{
+		Type:        "object",
+		Description: lookupDoc(elemType, ""),
+		Properties:  openapi3.Schemas{},
+	}
+	if elemType.Kind() != reflect.Struct {
+		return schema, nil
+	}
+	var fields []reflect.StructField
+	var owners []reflect.Type
+	for i := 0; i < elemType.NumField(); i++ {
+		field := elemType.Field(i)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = field.Type.Elem()
+		}
+		// inherited struct
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			schema, err := typeSchema(fieldType)
+			if err != nil {
+				return nil, errors.Errorf("field %s of %s: %v", field.Name, elemType, err)
+			}
+			if schema == nil {
+				for j := 0; j < fieldType.NumField(); j++ {
+					fields = append(fields, fieldType.Field(j))
+					owners = append(owners, fieldType)
 				}
+				continue
 			}
-			schemaRef := &openapi3.SchemaRef{Value: &openapi3.Schema{
-				Type:       "object",
-				Properties: properties,
-				Required:   requiredFields,
-			}}
-			typeName := namespace + elemType.Name()
-			o.model.Components.Schemas[typeName] = schemaRef
 		}
-	default:
-		panic(fmt.Sprintf("unsupported type %v for %s", elemType.Kind(), elemType.Name()))
+		fields = append(fields, field)
+		owners = append(owners, elemType)
 	}
-	if apiType == "array" {
-		return &openapi3.SchemaRef{
-			Value: &openapi3.Schema{Type: "array", Items: subType},
+	for i, field := range fields {
+		if !field.IsExported() {
+			continue
 		}
-	} else if apiType == "object" && subType != nil { // map
-		trueVal := true
-		return &openapi3.SchemaRef{
-			Value: &openapi3.Schema{Type: "object", AdditionalProperties: openapi3.AdditionalProperties{
-				Has:    &trueVal,
-				Schema: subType,
-			},
-			},
+
+		// bound from path, query, header or cookie instead of body
+		if in, _ := boundTag(field); in != "" {
+			continue
 		}
-	} else if apiType == "object" {
-		return &openapi3.SchemaRef{Ref: schemaPrefix + elemType.Name()}
-	}
-	return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: apiType}}
-}
 
-var swaggerHTML = `<!DOCTYPE html>
-<html lang="en">
-  <head>
-    <meta charset="utf-8" />
-    <meta name="viewport" content="width=device-width, initial-scale=1" />
-    <meta
-      name="description"
-      content="SwaggerUI"
-    />
-    <title>SwaggerUI</title>
-    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@4.5.0/swagger-ui.css" />
-  </head>
-  <body>
-  <div id="swagger-ui"></div>
-  <script src="https://unpkg.com/swagger-ui-dist@4.5.0/swagger-ui-bundle.js" crossorigin></script>
-  <script src="https://unpkg.com/swagger-ui-dist@4.5.0/swagger-ui-standalone-preset.js" crossorigin></script>
-  <script>
-    window.onload = () => {
-      window.ui = SwaggerUIBundle({
-        url: '%sapi.json',
-        dom_id: '#swagger-ui',
-        presets: [
-          SwaggerUIBundle.presets.apis,
-          SwaggerUIStandalonePreset
-        ],
-        layout: "StandaloneLayout",
-      });
-    };
-  </script>
-  </body>
-</html>`
+		fieldTag := field.Tag.Get(filedNameTag)
+		if fieldTag == "-" {
+			continue
+		}
+		if idx := strings.IndexRune(fieldTag, ','); idx >= 0 {
+			fieldTag = fieldTag[:idx]
+		}
+		if fieldTag == "" {
+			fieldTag = field.Name
+			fieldTag = strings.ToLower(fieldTag[:1]) + fieldTag[1:]
+		}
+
+		fieldSchema, err := o.parseType(namespace, field.Type)
+		if err != nil {
+			return nil, errors.Errorf("field %s of %s: %v", field.Name, elemType, err)
+		}
+		validateTag := field.Tag.Get("validate")
+		if isRequired(validateTag) {
+			schema.Required = append(schema.Required, fieldTag)
+		}
 
-var docHTML = `
-<!DOCTYPE html>
-<html>
-  <head>
-    <title>API Document</title>
-    <!-- needed for adaptive design -->
-    <meta charset="utf-8"/>
-    <meta name="viewport" content="width=device-width, initial-scale=1">
-    <link href="https://fonts.googleapis.com/css?family=Montserrat:300,400,700|Roboto:300,400,700" rel="stylesheet">
+		if desc := fieldDescription(owners[i], field); desc != "" && fieldSchema.Value != nil {
+			fieldSchema.Value.Description = desc
+		}
+		applyValidation(fieldSchema, validateTag)
 
-    <!--
-    Redoc doesn't change outer page styles
-    -->
-    <style>
-      body {
-        margin: 0;
-        padding: 0;
-      }
-    </style>
-  </head>
-  <body>
-    <redoc spec-url='%sapi.json'></redoc>
-    <script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"> </script>
-  </body>
-</html>`
+		schema.Properties[fieldTag] = fieldSchema
+	}
+	return schema, nil
+}
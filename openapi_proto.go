@@ -0,0 +1,121 @@
+package goapi
+
+import (
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// schemas of well-known types follow the JSON mapping of protojson
+var protoWellKnownSchemas = map[protoreflect.FullName]func() *openapi3.Schema{
+	"google.protobuf.Timestamp": func() *openapi3.Schema { return &openapi3.Schema{Type: "string", Format: "date-time"} },
+	"google.protobuf.Duration":  func() *openapi3.Schema { return &openapi3.Schema{Type: "string", Example: "1.5s"} },
+	"google.protobuf.FieldMask": func() *openapi3.Schema { return &openapi3.Schema{Type: "string"} },
+	"google.protobuf.Struct":    func() *openapi3.Schema { return openapi3.NewObjectSchema() },
+	"google.protobuf.Value":     func() *openapi3.Schema { return &openapi3.Schema{} },
+	"google.protobuf.ListValue": func() *openapi3.Schema { return openapi3.NewArraySchema().WithItems(&openapi3.Schema{}) },
+	"google.protobuf.Empty":     func() *openapi3.Schema { return openapi3.NewObjectSchema() },
+	"google.protobuf.Any": func() *openapi3.Schema {
+		return openapi3.NewObjectSchema().WithProperty("@type", openapi3.NewStringSchema())
+	},
+	"google.protobuf.BoolValue":   func() *openapi3.Schema { return openapi3.NewBoolSchema() },
+	"google.protobuf.StringValue": func() *openapi3.Schema { return openapi3.NewStringSchema() },
+	"google.protobuf.BytesValue":  func() *openapi3.Schema { return openapi3.NewBytesSchema() },
+	"google.protobuf.Int32Value":  func() *openapi3.Schema { return openapi3.NewInt32Schema() },
+	"google.protobuf.UInt32Value": func() *openapi3.Schema { return openapi3.NewInt32Schema().WithMin(0) },
+	"google.protobuf.Int64Value":  func() *openapi3.Schema { return &openapi3.Schema{Type: "string", Format: "int64"} },
+	"google.protobuf.UInt64Value": func() *openapi3.Schema { return &openapi3.Schema{Type: "string", Format: "uint64"} },
+	"google.protobuf.FloatValue":  func() *openapi3.Schema { return &openapi3.Schema{Type: "number", Format: "float"} },
+	"google.protobuf.DoubleValue": func() *openapi3.Schema { return &openapi3.Schema{Type: "number", Format: "double"} },
+}
+
+// protoGoName returns the name of generated Go type, nested message is joined by underscore
+func protoGoName(desc protoreflect.Descriptor) string {
+	name := strings.TrimPrefix(string(desc.FullName()), string(desc.ParentFile().Package())+".")
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+// parseProtoMessage derives schema from message descriptor instead of Go struct
+func (o *openapi) parseProtoMessage(namespace string, md protoreflect.MessageDescriptor) (*openapi3.SchemaRef, error) {
+	if wk, ok := protoWellKnownSchemas[md.FullName()]; ok {
+		return &openapi3.SchemaRef{Value: wk()}, nil
+	}
+	typeName := namespace + protoGoName(md)
+	ref := &openapi3.SchemaRef{Ref: schemaPrefix + typeName}
+	if exists, err := o.checkSchemaOwner(typeName, "proto:"+string(md.FullName())); exists || err != nil {
+		return ref, err
+	}
+
+	schema := openapi3.NewObjectSchema()
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		fieldSchema, err := o.parseProtoField(namespace, fd)
+		if err != nil {
+			return nil, err
+		}
+		if oneof := fd.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() && fieldSchema.Value != nil {
+			fieldSchema.Value.Description = "Only one field of oneof " + string(oneof.Name()) + " can be set"
+		}
+		if fd.Cardinality() == protoreflect.Required {
+			schema.Required = append(schema.Required, fd.JSONName())
+		}
+		schema.Properties[fd.JSONName()] = fieldSchema
+	}
+	o.model.Components.Schemas[typeName] = &openapi3.SchemaRef{Value: schema}
+	return ref, nil
+}
+
+func (o *openapi) parseProtoField(namespace string, fd protoreflect.FieldDescriptor) (*openapi3.SchemaRef, error) {
+	if fd.IsMap() {
+		value, err := o.parseProtoSingular(namespace, fd.MapValue())
+		if err != nil {
+			return nil, err
+		}
+		hasValue := true
+		return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "object", AdditionalProperties: openapi3.AdditionalProperties{
+			Has:    &hasValue,
+			Schema: value,
+		}}}, nil
+	}
+	item, err := o.parseProtoSingular(namespace, fd)
+	if err != nil || !fd.IsList() {
+		return item, err
+	}
+	return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "array", Items: item}}, nil
+}
+
+func (o *openapi) parseProtoSingular(namespace string, fd protoreflect.FieldDescriptor) (*openapi3.SchemaRef, error) {
+	var schema *openapi3.Schema
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		schema = openapi3.NewBoolSchema()
+	case protoreflect.StringKind:
+		schema = openapi3.NewStringSchema()
+	case protoreflect.BytesKind:
+		schema = openapi3.NewBytesSchema()
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		schema = openapi3.NewInt32Schema()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		schema = openapi3.NewInt32Schema().WithMin(0)
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// 64-bit integers are encoded as string by protojson
+		schema = &openapi3.Schema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		schema = &openapi3.Schema{Type: "string", Format: "uint64"}
+	case protoreflect.FloatKind:
+		schema = &openapi3.Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		schema = &openapi3.Schema{Type: "number", Format: "double"}
+	case protoreflect.EnumKind:
+		schema = openapi3.NewStringSchema()
+		values := fd.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			schema.Enum = append(schema.Enum, string(values.Get(i).Name()))
+		}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return o.parseProtoMessage(namespace, fd.Message())
+	}
+	return &openapi3.SchemaRef{Value: schema}, nil
+}
@@ -0,0 +1,168 @@
+package goapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/apipb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestParseProtoMessage(t *testing.T) {
+	o := newOpenapi()
+	ref, err := o.parseType("Svc", reflect.TypeOf(&apipb.Api{}))
+	assert.Nil(t, err)
+	assert.Equal(t, schemaPrefix+"SvcApi", ref.Ref)
+
+	api := o.model.Components.Schemas["SvcApi"].Value
+	assert.Equal(t, "string", api.Properties["name"].Value.Type)
+	assert.Equal(t, "array", api.Properties["methods"].Value.Type)
+	assert.Equal(t, schemaPrefix+"SvcMethod", api.Properties["methods"].Value.Items.Ref)
+	assert.Equal(t, []interface{}{"SYNTAX_PROTO2", "SYNTAX_PROTO3", "SYNTAX_EDITIONS"}, api.Properties["syntax"].Value.Enum)
+	assert.Equal(t, schemaPrefix+"SvcSourceContext", api.Properties["sourceContext"].Ref)
+
+	method := o.model.Components.Schemas["SvcMethod"].Value
+	assert.Equal(t, "boolean", method.Properties["requestStreaming"].Value.Type)
+
+	option := o.model.Components.Schemas["SvcOption"].Value
+	assert.Equal(t, "@type", func() string {
+		for k := range option.Properties["value"].Value.Properties {
+			return k
+		}
+		return ""
+	}())
+
+	ref, err = o.parseType("Svc", reflect.TypeOf(&structpb.Struct{}))
+	assert.Nil(t, err)
+	assert.Equal(t, "object", ref.Value.Type)
+}
+
+type constrainedReq struct {
+	Page  int               `query:"page" validate:"gte=1,lte=100"`
+	Name  string            `json:"name" validate:"required,min=1,max=20,alphanum"`
+	Email string            `json:"email" validate:"omitempty,email"`
+	Kind  string            `json:"kind" validate:"oneof=a b c"`
+	Level int               `json:"level" validate:"oneof=1 2 3"`
+	Rate  float64           `json:"rate" validate:"gt=0,lt=1"`
+	Tags  []string          `json:"tags" validate:"min=1,max=5,unique,dive,uuid4"`
+	Attrs map[string]string `json:"attrs" validate:"max=3,dive,max=10"`
+	Notes []string          `json:"notes" validate:"dive,required"`
+}
+
+func TestValidateConstraints(t *testing.T) {
+	o := newOpenapi()
+	_, err := o.parseType("Svc", reflect.TypeOf(&constrainedReq{}))
+	assert.Nil(t, err)
+	schema := o.model.Components.Schemas["SvcconstrainedReq"].Value
+
+	assert.Equal(t, []string{"name"}, schema.Required)
+	name := schema.Properties["name"].Value
+	assert.Equal(t, uint64(1), name.MinLength)
+	assert.Equal(t, uint64(20), *name.MaxLength)
+	assert.Equal(t, "^[a-zA-Z0-9]+$", name.Pattern)
+	assert.Equal(t, "email", schema.Properties["email"].Value.Format)
+	assert.Equal(t, []interface{}{"a", "b", "c"}, schema.Properties["kind"].Value.Enum)
+	assert.Equal(t, []interface{}{int64(1), int64(2), int64(3)}, schema.Properties["level"].Value.Enum)
+
+	rate := schema.Properties["rate"].Value
+	assert.Equal(t, 0.0, *rate.Min)
+	assert.True(t, rate.ExclusiveMin)
+	assert.Equal(t, 1.0, *rate.Max)
+	assert.True(t, rate.ExclusiveMax)
+
+	tags := schema.Properties["tags"].Value
+	assert.Equal(t, uint64(1), tags.MinItems)
+	assert.Equal(t, uint64(5), *tags.MaxItems)
+	assert.True(t, tags.UniqueItems)
+	assert.Equal(t, "uuid", tags.Items.Value.Format)
+
+	attrs := schema.Properties["attrs"].Value
+	assert.Equal(t, uint64(3), *attrs.MaxProps)
+	assert.Equal(t, uint64(10), *attrs.AdditionalProperties.Schema.Value.MaxLength)
+
+	page := &openapi3.SchemaRef{Value: openapi3.NewIntegerSchema()}
+	applyValidation(page, "gte=1,lte=100")
+	assert.Equal(t, 1.0, *page.Value.Min)
+	assert.Equal(t, 100.0, *page.Value.Max)
+}
+
+type money struct {
+	Amount   int64
+	Currency string
+}
+
+func (m money) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.FormatInt(m.Amount, 10) + " " + m.Currency + `"`), nil
+}
+
+func (m money) JSONSchema() *openapi3.Schema {
+	return &openapi3.Schema{Type: "string", Example: "100 USD"}
+}
+
+type level int
+
+func (l level) MarshalText() ([]byte, error) {
+	return []byte(strconv.Itoa(int(l))), nil
+}
+
+type address struct {
+	City string `json:"city"`
+}
+
+// pricer is an interface type embedding SchemaProvider
+type pricer interface {
+	SchemaProvider
+	Price() int64
+}
+
+type wellKnownReq struct {
+	CreatedAt time.Time         `json:"createdAt"`
+	Timeout   time.Duration     `json:"timeout"`
+	Data      []byte            `json:"data"`
+	Raw       json.RawMessage   `json:"raw"`
+	Price     money             `json:"price"`
+	Levels    map[level]**int   `json:"levels"`
+	Address   *address          `json:"address"`
+	Extra     map[int]string    `json:",omitempty"`
+	Handler   func()            `json:"-"`
+	Notes     map[string]string `json:"notes"`
+	Pricer    pricer            `json:"pricer"`
+}
+
+func TestWellKnownTypes(t *testing.T) {
+	o := newOpenapi()
+	_, err := o.parseType("Svc", reflect.TypeOf(&wellKnownReq{}))
+	assert.Nil(t, err)
+	schema := o.model.Components.Schemas["SvcwellKnownReq"].Value
+
+	assert.Equal(t, "date-time", schema.Properties["createdAt"].Value.Format)
+	assert.Equal(t, "int64", schema.Properties["timeout"].Value.Format)
+	assert.Equal(t, "byte", schema.Properties["data"].Value.Format)
+	assert.Equal(t, "", schema.Properties["raw"].Value.Type)
+	assert.Equal(t, "100 USD", schema.Properties["price"].Value.Example)
+	assert.Equal(t, "integer", schema.Properties["levels"].Value.AdditionalProperties.Schema.Value.Type)
+	assert.Equal(t, schemaPrefix+"Svcaddress", schema.Properties["address"].Ref)
+	assert.NotNil(t, o.model.Components.Schemas["Svcaddress"])
+	assert.Contains(t, schema.Properties, "extra")
+	assert.Equal(t, schemaPrefix+"Svcpricer", schema.Properties["pricer"].Ref)
+	schema, err = typeSchema(reflect.TypeOf((*pricer)(nil)).Elem())
+	assert.Nil(t, schema)
+	assert.Nil(t, err)
+
+	type badReq struct {
+		Value complex128
+	}
+	_, err = o.parseType("Svc", reflect.TypeOf(&badReq{}))
+	assert.EqualError(t, err, "field Value of goapi.badReq: unsupported type complex128 in Svc")
+
+	type badMapReq struct {
+		Value map[float64]string
+	}
+	_, err = o.parseType("Svc", reflect.TypeOf(&badMapReq{}))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,130 @@
+package goapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"math/big"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ottstack/goapi/pkg/coder"
+)
+
+// SchemaProvider can be implemented by types with custom JSON encoding to
+// describe their schema in OpenAPI document, a new schema should be returned
+// by each call.
+type SchemaProvider interface {
+	JSONSchema() *openapi3.Schema
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	schemaProvider    = reflect.TypeOf((*SchemaProvider)(nil)).Elem()
+)
+
+// schemas of well-known types follow their JSON encoding
+var wellKnownSchemas = map[reflect.Type]func() *openapi3.Schema{
+	reflect.TypeOf(time.Time{}):       openapi3.NewDateTimeSchema,
+	reflect.TypeOf(time.Duration(0)):  durationSchema,
+	reflect.TypeOf(json.RawMessage{}): func() *openapi3.Schema { return &openapi3.Schema{} },
+	reflect.TypeOf(json.Number("")):   func() *openapi3.Schema { return &openapi3.Schema{Type: "number"} },
+	reflect.TypeOf(net.IP{}):          func() *openapi3.Schema { return openapi3.NewStringSchema().WithFormat("ip") },
+	reflect.TypeOf(big.Int{}):         openapi3.NewIntegerSchema,
+	reflect.TypeOf(big.Float{}):       func() *openapi3.Schema { return openapi3.NewStringSchema().WithFormat("decimal") },
+}
+
+// time.Duration is encoded as integer of nanoseconds
+func durationSchema() *openapi3.Schema {
+	schema := openapi3.NewInt64Schema()
+	schema.Description = "Duration in nanoseconds"
+	return schema
+}
+
+// schemas of well-known third-party types by package path and name, the
+// packages are not imported
+var wellKnownTypeNames = map[string]func() *openapi3.Schema{
+	"github.com/google/uuid.UUID":           openapi3.NewUUIDSchema,
+	"github.com/gofrs/uuid.UUID":            openapi3.NewUUIDSchema,
+	"github.com/satori/go.uuid.UUID":        openapi3.NewUUIDSchema,
+	"github.com/shopspring/decimal.Decimal": func() *openapi3.Schema { return openapi3.NewStringSchema().WithFormat("decimal") },
+}
+
+var registeredSchemas = struct {
+	sync.RWMutex
+	m map[reflect.Type]func() *openapi3.Schema
+}{m: map[reflect.Type]func() *openapi3.Schema{}}
+
+// RegisterSchema sets schema of the type of v in OpenAPI document, it's used
+// for the types of other packages without JSONSchema method
+//
+//	goapi.RegisterSchema(decimal.Decimal{}, func() *openapi3.Schema {
+//		return openapi3.NewStringSchema().WithFormat("decimal")
+//	})
+func RegisterSchema(v interface{}, schema func() *openapi3.Schema) {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	registeredSchemas.Lock()
+	defer registeredSchemas.Unlock()
+	registeredSchemas.m[t] = schema
+}
+
+// typeSchema returns schema of registered, well-known or custom marshaled types,
+// nil if t should be parsed by kind. The error is returned if t has invalid enum values.
+func typeSchema(t reflect.Type) (*openapi3.Schema, error) {
+	registeredSchemas.RLock()
+	fn, ok := registeredSchemas.m[t]
+	registeredSchemas.RUnlock()
+	if ok {
+		return fn(), nil
+	}
+	// JSONSchema can't be called without a value of interface type
+	if t.Kind() != reflect.Interface && implements(t, schemaProvider) {
+		return reflect.New(t).Interface().(SchemaProvider).JSONSchema(), nil
+	}
+	if schema, err := enumSchema(t); schema != nil || err != nil {
+		return schema, err
+	}
+	if fn, ok := wellKnownSchemas[t]; ok {
+		return fn(), nil
+	}
+	if fn, ok := wellKnownTypeNames[t.PkgPath()+"."+t.Name()]; ok {
+		return fn(), nil
+	}
+	// proto messages are encoded by protojson
+	if coder.IsProtoMessage(t) {
+		return nil, nil
+	}
+	// MarshalJSON takes priority over MarshalText, the encoded value is unknown
+	if implements(t, jsonMarshalerType) {
+		return &openapi3.Schema{}, nil
+	}
+	if implements(t, textMarshalerType) {
+		return openapi3.NewStringSchema(), nil
+	}
+	// []byte is encoded as base64 string
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return openapi3.NewBytesSchema(), nil
+	}
+	return nil, nil
+}
+
+// implements returns whether t or *t implements interface it
+func implements(t, it reflect.Type) bool {
+	return t.Implements(it) || reflect.PtrTo(t).Implements(it)
+}
+
+// isMapKey returns whether t can be the key type of map in JSON
+func isMapKey(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return implements(t, textMarshalerType)
+}
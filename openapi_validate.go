@@ -0,0 +1,199 @@
+package goapi
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// formats of validate rules
+var validateFormats = map[string]string{
+	"email":    "email",
+	"uuid":     "uuid",
+	"uuid3":    "uuid",
+	"uuid4":    "uuid",
+	"uuid5":    "uuid",
+	"url":      "uri",
+	"uri":      "uri",
+	"hostname": "hostname",
+	"ip":       "ip",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+}
+
+// patterns of validate rules
+var validatePatterns = map[string]string{
+	"alpha":       "^[a-zA-Z]+$",
+	"alphanum":    "^[a-zA-Z0-9]+$",
+	"numeric":     "^[-+]?[0-9]+(?:\\.[0-9]+)?$",
+	"number":      "^[0-9]+$",
+	"hexadecimal": "^(0[xX])?[0-9a-fA-F]+$",
+	"lowercase":   "^[^A-Z]*$",
+	"uppercase":   "^[^a-z]*$",
+	"e164":        "^\\+[1-9]?[0-9]{7,14}$",
+}
+
+// validateRules splits validate tag into rules, or-combined rules like "email|url" are skipped
+func validateRules(tag string) [][2]string {
+	var rules [][2]string
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "" || strings.Contains(rule, "|") {
+			continue
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		rules = append(rules, [2]string{name, param})
+	}
+	return rules
+}
+
+// isRequired returns whether the field has required rule before dive
+func isRequired(tag string) bool {
+	for _, rule := range validateRules(tag) {
+		switch rule[0] {
+		case "required":
+			return true
+		case "dive":
+			return false
+		}
+	}
+	return false
+}
+
+// applyValidation translates validate tag into JSON Schema keywords of schema,
+// the rules after dive apply to items of array or values of map
+func applyValidation(ref *openapi3.SchemaRef, tag string) {
+	if ref == nil || ref.Value == nil || tag == "" {
+		return
+	}
+	schema := ref.Value
+	rules := validateRules(tag)
+	for i, rule := range rules {
+		name, param := rule[0], rule[1]
+		switch name {
+		case "dive":
+			var rest []string
+			for _, r := range rules[i+1:] {
+				if r[0] == "keys" || r[0] == "endkeys" {
+					return
+				}
+				if r[1] != "" {
+					rest = append(rest, r[0]+"="+r[1])
+				} else {
+					rest = append(rest, r[0])
+				}
+			}
+			if schema.Items != nil {
+				applyValidation(schema.Items, strings.Join(rest, ","))
+			} else if schema.AdditionalProperties.Schema != nil {
+				applyValidation(schema.AdditionalProperties.Schema, strings.Join(rest, ","))
+			}
+			return
+		case "min", "gte":
+			setMin(schema, param, false)
+		case "max", "lte":
+			setMax(schema, param, false)
+		case "gt":
+			setMin(schema, param, true)
+		case "lt":
+			setMax(schema, param, true)
+		case "len":
+			setMin(schema, param, false)
+			setMax(schema, param, false)
+		case "oneof":
+			schema.Enum = nil
+			for _, v := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, enumValue(schema.Type, v))
+			}
+		case "unique":
+			if schema.Type == "array" {
+				schema.UniqueItems = true
+			}
+		case "startswith":
+			schema.Pattern = "^" + regexp.QuoteMeta(param)
+		case "endswith":
+			schema.Pattern = regexp.QuoteMeta(param) + "$"
+		case "contains":
+			schema.Pattern = regexp.QuoteMeta(param)
+		default:
+			if format, ok := validateFormats[name]; ok && schema.Type == "string" {
+				schema.Format = format
+			} else if pattern, ok := validatePatterns[name]; ok && schema.Type == "string" {
+				schema.Pattern = pattern
+			}
+		}
+	}
+}
+
+// setMin sets lower bound by type, exclusive bound of length and size is converted to inclusive
+func setMin(schema *openapi3.Schema, param string, exclusive bool) {
+	switch schema.Type {
+	case "integer", "number":
+		v, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		schema.Min = &v
+		schema.ExclusiveMin = exclusive
+	case "string", "array", "object":
+		n, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return
+		}
+		if exclusive {
+			n++
+		}
+		switch schema.Type {
+		case "string":
+			schema.MinLength = n
+		case "array":
+			schema.MinItems = n
+		default:
+			schema.MinProps = n
+		}
+	}
+}
+
+// setMax sets upper bound by type, exclusive bound of length and size is converted to inclusive
+func setMax(schema *openapi3.Schema, param string, exclusive bool) {
+	switch schema.Type {
+	case "integer", "number":
+		v, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		schema.Max = &v
+		schema.ExclusiveMax = exclusive
+	case "string", "array", "object":
+		n, err := strconv.ParseUint(param, 10, 64)
+		if err != nil || (exclusive && n == 0) {
+			return
+		}
+		if exclusive {
+			n--
+		}
+		switch schema.Type {
+		case "string":
+			schema.MaxLength = &n
+		case "array":
+			schema.MaxItems = &n
+		default:
+			schema.MaxProps = &n
+		}
+	}
+}
+
+func enumValue(schemaType, v string) interface{} {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
@@ -0,0 +1,82 @@
+package goapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/ottstack/goapi/pkg/middleware"
+	"github.com/valyala/fasthttp"
+)
+
+// MethodOptions configures the middlewares and policies of a service method
+type MethodOptions struct {
+	// Middlewares run after the ones of server and group, like rate limiter of the method
+	Middlewares []middleware.Middleware
+	// Security lists the security schemes accepted by the method, any of them is sufficient.
+	// Scopes required can follow the name like "jwt:read,write". It is documented as
+	// security requirements and enforced by the middleware of Server.UseAuth,
+	// Serve fails if any scheme is not added by UseAuth.
+	Security []string
+	// Timeout cancels the context passed to middlewares and method, zero means no timeout
+	Timeout time.Duration
+	// MaxBodySize limits the request body in bytes, zero means SERVE_MAX_BODY_SIZE which
+	// is 4 MB by default. Bodies are read up to the largest limit of server and methods
+	// before being checked, so a smaller limit doesn't avoid reading the body.
+	MaxBodySize int
+	// Extensions are added to the OpenAPI operation, keys should start with "x-"
+	Extensions map[string]interface{}
+}
+
+// ServiceMethodOptions can be implemented by a service to configure its methods,
+// keys are method names.
+type ServiceMethodOptions interface {
+	MethodOptions() map[string]MethodOptions
+}
+
+// isServiceHook returns whether the method implements optional service interface instead of API
+func isServiceHook(sv interface{}, name string) bool {
+	switch name {
+	case "Routes":
+		_, ok := sv.(ServiceRoutes)
+		return ok
+	case "MethodOptions":
+		_, ok := sv.(ServiceMethodOptions)
+		return ok
+	case "Description":
+		_, ok := sv.(ServiceDescription)
+		return ok
+	}
+	return false
+}
+
+// checkBodySize returns 413 error if request body exceeds MaxBodySize of method,
+// or serverLimit if it's not set
+func (o *MethodOptions) checkBodySize(fastReq *fasthttp.RequestCtx, serverLimit int) error {
+	limit := o.MaxBodySize
+	if limit <= 0 {
+		limit = serverLimit
+	}
+	if len(fastReq.PostBody()) > limit {
+		return &ecode.APIError{Code: 413, Message: fmt.Sprintf("Request body exceeds %d bytes", limit)}
+	}
+	return nil
+}
+
+// withTimeout wraps method to run with Timeout of method
+func (o *MethodOptions) withTimeout(method middleware.MethodFunc) middleware.MethodFunc {
+	if o.Timeout <= 0 {
+		return method
+	}
+	return func(ctx context.Context, req, rsp interface{}) error {
+		ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+		err := method(ctx, req, rsp)
+		if err != nil && errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+			return &ecode.APIError{Code: 504, Message: fmt.Sprintf("Request timeout after %s", o.Timeout)}
+		}
+		return err
+	}
+}
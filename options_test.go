@@ -0,0 +1,108 @@
+package goapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ottstack/goapi/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type optionsReq struct {
+	Name string `json:"name"`
+}
+
+type optionsRsp struct {
+	Message string `json:"message"`
+}
+
+type OptionsService struct{}
+
+func (s *OptionsService) MethodOptions() map[string]MethodOptions {
+	return map[string]MethodOptions{
+		"Hello": {
+			Middlewares: []middleware.Middleware{func(ctx context.Context, fastReq *fasthttp.RequestCtx, method middleware.MethodFunc, req, rsp interface{}) error {
+				fastReq.Response.Header.Set("X-Method", middleware.GetCallInfo(ctx).MethodName)
+				return method(ctx, req, rsp)
+			}},
+			Security:    []string{"bearer"},
+			MaxBodySize: 20,
+			Extensions:  map[string]interface{}{"x-internal": true},
+		},
+		"Slow": {Timeout: 10 * time.Millisecond},
+	}
+}
+
+func (s *OptionsService) Hello(ctx context.Context, req *optionsReq, rsp *optionsRsp) error {
+	rsp.Message = "hello " + req.Name
+	return nil
+}
+
+func (s *OptionsService) Slow(ctx context.Context, req *optionsReq, rsp *optionsRsp) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestMethodOptions(t *testing.T) {
+	s := NewServer()
+	assert.Nil(t, s.parse(s.root, []interface{}{&OptionsService{}}))
+
+	fastReq := doRequest(s, "POST", "/api/OptionsService/Hello", `{"name":"goapi"}`)
+	assert.Equal(t, 200, fastReq.Response.StatusCode())
+	assert.Equal(t, "Hello", string(fastReq.Response.Header.Peek("X-Method")))
+
+	fastReq = doRequest(s, "POST", "/api/OptionsService/Hello", `{"name":"too long to accept"}`)
+	assert.Equal(t, 413, fastReq.Response.StatusCode())
+
+	fastReq = doRequest(s, "POST", "/api/OptionsService/Slow", `{}`)
+	assert.Contains(t, string(fastReq.Response.Body()), "timeout")
+
+	oper := s.api.model.Paths["/api/OptionsService/Hello"].Post
+	assert.Equal(t, true, oper.Extensions["x-internal"])
+	assert.Contains(t, (*oper.Security)[0], "bearer")
+}
+
+type BodyService struct{}
+
+func (s *BodyService) MethodOptions() map[string]MethodOptions {
+	return map[string]MethodOptions{"Upload": {MaxBodySize: 5 << 20}}
+}
+
+func (s *BodyService) Upload(ctx context.Context, req *optionsReq, rsp *optionsRsp) error {
+	rsp.Message = strconv.Itoa(len(req.Name))
+	return nil
+}
+
+func (s *BodyService) Hello(ctx context.Context, req *optionsReq, rsp *optionsRsp) error {
+	return nil
+}
+
+func TestMaxBodySize(t *testing.T) {
+	t.Setenv("SERVE_MAX_BODY_SIZE", "16")
+	s := NewServer()
+	s.RegisterService(&BodyService{})
+	assert.Equal(t, 5<<20, s.requestBodyLimit())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	addr, _ := startServer(t, ctx, s)
+
+	post := func(method, body string) int {
+		rsp, err := http.Post("http://"+addr+"/api/BodyService/"+method, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rsp.Body.Close()
+		return rsp.StatusCode
+	}
+	// limit of method above the default 4 MB of fasthttp
+	name := strings.Repeat("a", 9<<19)
+	assert.Equal(t, 200, post("Upload", `{"name":"`+name+`"}`))
+	// methods without limit use SERVE_MAX_BODY_SIZE
+	assert.Equal(t, 200, post("Hello", `{"name":"a"}`))
+	assert.Equal(t, 413, post("Hello", `{"name":"goapi!"}`))
+}
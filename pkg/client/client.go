@@ -43,7 +43,7 @@ func (c *Client) Call(ctx context.Context, path string, req interface{}, rsp int
 	if err != nil {
 		return fmt.Errorf("io.ReadAll error %v", err)
 	}
-	if httpRsp.StatusCode != 200 {
+	if httpRsp.StatusCode < 200 || httpRsp.StatusCode >= 300 {
 		e := &ecode.APIError{}
 		if err := json.Unmarshal(bs, e); err != nil {
 			return fmt.Errorf("json.Unmarshal error %v", err)
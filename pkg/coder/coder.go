@@ -0,0 +1,203 @@
+package coder
+
+import (
+	"mime"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Codec encodes and decodes request and response of a media type
+type Codec interface {
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// TypeChecker can be implemented by codec which only supports some types
+type TypeChecker interface {
+	Supports(t reflect.Type) bool
+}
+
+// Default is used when the request does not specify Content-Type or Accept
+var Default Codec = JSON
+
+// Set is a group of codecs indexed by media type
+type Set struct {
+	codecs       map[string]Codec
+	contentTypes []string
+}
+
+var registry = &Set{codecs: map[string]Codec{}}
+
+func init() {
+	Register(JSON)
+	Register(MsgPack, "application/x-msgpack")
+	Register(Proto, "application/protobuf")
+}
+
+// Register adds codec for its content type and the alias media types,
+// the codec registered later replaces the former one of the same media type
+func Register(c Codec, aliases ...string) {
+	registry.add(c, aliases...)
+}
+
+func (s *Set) add(c Codec, aliases ...string) {
+	ct := c.ContentType()
+	if _, ok := s.codecs[ct]; !ok {
+		s.contentTypes = append(s.contentTypes, ct)
+	}
+	s.codecs[ct] = c
+	for _, alias := range aliases {
+		s.codecs[alias] = c
+	}
+}
+
+// For returns the registered codecs supporting all of the types
+func For(types ...reflect.Type) *Set {
+	s := &Set{codecs: map[string]Codec{}}
+	for mediaType, c := range registry.codecs {
+		if supports(c, types) {
+			s.codecs[mediaType] = c
+		}
+	}
+	for _, ct := range registry.contentTypes {
+		if _, ok := s.codecs[ct]; ok {
+			s.contentTypes = append(s.contentTypes, ct)
+		}
+	}
+	return s
+}
+
+func supports(c Codec, types []reflect.Type) bool {
+	tc, ok := c.(TypeChecker)
+	if !ok {
+		return true
+	}
+	for _, t := range types {
+		if !tc.Supports(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContentTypes returns the media types of registered codecs in registration order
+func ContentTypes() []string {
+	return registry.ContentTypes()
+}
+
+// Get returns the registered codec for Content-Type header
+func Get(contentType string) (Codec, bool) {
+	return registry.Get(contentType)
+}
+
+// Negotiate returns the registered codec for Accept header
+func Negotiate(accept string) (Codec, bool) {
+	return registry.Negotiate(accept)
+}
+
+// ContentTypes returns the media types of codecs in registration order
+func (s *Set) ContentTypes() []string {
+	return append([]string(nil), s.contentTypes...)
+}
+
+// defaultCodec returns Default if it is in the set, otherwise the first registered one
+func (s *Set) defaultCodec() (Codec, bool) {
+	if c, ok := s.codecs[Default.ContentType()]; ok {
+		return c, true
+	}
+	if len(s.contentTypes) > 0 {
+		return s.codecs[s.contentTypes[0]], true
+	}
+	return nil, false
+}
+
+// Get returns the codec for Content-Type header, the structured syntax suffix
+// like "application/problem+json" falls back to "application/json".
+func (s *Set) Get(contentType string) (Codec, bool) {
+	if strings.TrimSpace(contentType) == "" {
+		return s.defaultCodec()
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	if c, ok := s.codecs[mediaType]; ok {
+		return c, true
+	}
+	if idx := strings.LastIndexByte(mediaType, '+'); idx >= 0 {
+		c, ok := s.codecs["application/"+mediaType[idx+1:]]
+		return c, ok
+	}
+	return nil, false
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// Negotiate returns the codec for Accept header, the default one is returned if Accept is empty
+func (s *Set) Negotiate(accept string) (Codec, bool) {
+	c, _, ok := s.NegotiateMediaType(accept)
+	return c, ok
+}
+
+// NegotiateMediaType returns the codec for Accept header and the media type accepted,
+// which is a structured syntax suffix type like "application/problem+json" if it
+// is accepted with the highest quality and falls back to the codec
+func (s *Set) NegotiateMediaType(accept string) (Codec, string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return s.defaultMediaType()
+	}
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	for _, r := range ranges {
+		switch {
+		case r.mediaType == "*/*":
+			return s.defaultMediaType()
+		case strings.HasSuffix(r.mediaType, "/*"):
+			prefix := r.mediaType[:len(r.mediaType)-1]
+			if c, ok := s.defaultCodec(); ok && strings.HasPrefix(c.ContentType(), prefix) {
+				return c, c.ContentType(), true
+			}
+			for _, ct := range s.contentTypes {
+				if strings.HasPrefix(ct, prefix) {
+					return s.codecs[ct], ct, true
+				}
+			}
+		default:
+			if c, ok := s.Get(r.mediaType); ok {
+				return c, r.mediaType, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+func (s *Set) defaultMediaType() (Codec, string, bool) {
+	c, ok := s.defaultCodec()
+	if !ok {
+		return nil, "", false
+	}
+	return c, c.ContentType(), true
+}
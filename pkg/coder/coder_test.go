@@ -0,0 +1,108 @@
+package coder
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/apipb"
+)
+
+type message struct {
+	Name  string `json:"name"`
+	Count int    `json:"count,omitempty"`
+}
+
+func TestGet(t *testing.T) {
+	c, ok := Get("")
+	assert.True(t, ok)
+	assert.Equal(t, JSON, c)
+
+	c, ok = Get("application/json; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, JSON, c)
+
+	c, ok = Get("application/problem+json")
+	assert.True(t, ok)
+	assert.Equal(t, JSON, c)
+
+	c, ok = Get("application/x-msgpack")
+	assert.True(t, ok)
+	assert.Equal(t, MsgPack, c)
+
+	_, ok = Get("application/x-www-form-urlencoded")
+	assert.False(t, ok)
+}
+
+func TestNegotiate(t *testing.T) {
+	c, ok := Negotiate("")
+	assert.True(t, ok)
+	assert.Equal(t, JSON, c)
+
+	c, ok = Negotiate("text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	assert.True(t, ok)
+	assert.Equal(t, JSON, c)
+
+	c, ok = Negotiate("application/json;q=0.5, application/msgpack")
+	assert.True(t, ok)
+	assert.Equal(t, MsgPack, c)
+
+	_, ok = Negotiate("text/html, application/msgpack;q=0")
+	assert.False(t, ok)
+}
+
+func TestMsgPack(t *testing.T) {
+	bs, err := MsgPack.Marshal(&message{Name: "alice"})
+	assert.Nil(t, err)
+
+	m := map[string]interface{}{}
+	assert.Nil(t, MsgPack.Unmarshal(bs, &m))
+	assert.Equal(t, map[string]interface{}{"name": "alice"}, m)
+
+	msg := &message{}
+	assert.Nil(t, MsgPack.Unmarshal(bs, msg))
+	assert.Equal(t, "alice", msg.Name)
+}
+
+func TestProto(t *testing.T) {
+	msg := &apipb.Method{Name: "Get", RequestStreaming: true}
+	bs, err := Proto.Marshal(msg)
+	assert.Nil(t, err)
+	got := &apipb.Method{}
+	assert.Nil(t, Proto.Unmarshal(bs, got))
+	assert.Equal(t, "Get", got.Name)
+
+	_, err = Proto.Marshal(&message{})
+	assert.NotNil(t, err)
+
+	bs, err = JSON.Marshal(msg)
+	assert.Nil(t, err)
+	assert.Contains(t, string(bs), `"requestStreaming":true`)
+	got = &apipb.Method{}
+	assert.Nil(t, JSON.Unmarshal([]byte(`{"name":"List","unknown":1}`), got))
+	assert.Equal(t, "List", got.Name)
+
+	set := For(reflect.TypeOf(msg))
+	assert.Equal(t, []string{"application/json", "application/x-protobuf"}, set.ContentTypes())
+	set = For(reflect.TypeOf(&message{}))
+	assert.Equal(t, []string{"application/json", "application/msgpack"}, set.ContentTypes())
+	_, ok := set.Negotiate("application/x-protobuf")
+	assert.False(t, ok)
+}
+
+func TestNegotiateMediaType(t *testing.T) {
+	set := For(reflect.TypeOf(&message{}))
+	c, mediaType, ok := set.NegotiateMediaType("application/problem+json, application/json;q=0.5")
+	assert.True(t, ok)
+	assert.Equal(t, JSON, c)
+	assert.Equal(t, "application/problem+json", mediaType)
+
+	c, mediaType, ok = set.NegotiateMediaType("application/problem+json;q=0, */*")
+	assert.True(t, ok)
+	assert.Equal(t, JSON, c)
+	assert.Equal(t, "application/json", mediaType)
+
+	_, mediaType, ok = set.NegotiateMediaType("text/html")
+	assert.False(t, ok)
+	assert.Equal(t, "", mediaType)
+}
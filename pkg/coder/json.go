@@ -1 +1,32 @@
 package coder
+
+import (
+	json "github.com/goccy/go-json"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// JSON is the codec of application/json, proto.Message is encoded by protojson
+var JSON Codec = jsonCodec{}
+
+var protojsonUnmarshal = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return protojson.Marshal(m)
+	}
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	if m, ok := v.(proto.Message); ok {
+		return protojsonUnmarshal.Unmarshal(data, m)
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,47 @@
+package coder
+
+import (
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Proto is the codec of application/x-protobuf, only proto.Message is supported
+var Proto Codec = protoCodec{}
+
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// IsProtoMessage reports whether t or pointer to t implements proto.Message
+func IsProtoMessage(t reflect.Type) bool {
+	if t.Kind() != reflect.Ptr {
+		t = reflect.PtrTo(t)
+	}
+	return t.Implements(protoMessageType)
+}
+
+type protoCodec struct{}
+
+func (protoCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (protoCodec) Supports(t reflect.Type) bool {
+	return IsProtoMessage(t)
+}
+
+func (protoCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is not proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protoCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
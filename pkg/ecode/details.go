@@ -0,0 +1,100 @@
+package ecode
+
+import (
+	"math"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// FieldViolation describes an invalid field of request
+type FieldViolation struct {
+	// Path of field like "items[0].name"
+	Field string `json:"field"`
+	// Why the field is invalid
+	Description string `json:"description"`
+	// Rule violated like "required" or "max"
+	Rule string `json:"rule,omitempty"`
+	// Parameter of rule like 10 of "max=10"
+	Param string `json:"param,omitempty"`
+}
+
+// DebugInfo carries the internal detail of error for debugging
+type DebugInfo struct {
+	Detail       string   `json:"detail,omitempty"`
+	StackEntries []string `json:"stackEntries,omitempty"`
+}
+
+// Detail is a typed payload of error, the type tells clients how to decode the value
+type Detail struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// WithViolation appends an invalid field
+func (e *APIError) WithViolation(field, description string) *APIError {
+	e.Violations = append(e.Violations, FieldViolation{Field: field, Description: description})
+	return e
+}
+
+// WithRetryAfter sets the delay before retry, it is also sent as Retry-After header
+func (e *APIError) WithRetryAfter(d time.Duration) *APIError {
+	e.RetryAfter = int(math.Ceil(d.Seconds()))
+	return e
+}
+
+// WithDebug attaches detail and current stack for debugging
+func (e *APIError) WithDebug(detail string) *APIError {
+	stack := strings.Split(strings.TrimSpace(string(debug.Stack())), "\n")
+	e.Debug = &DebugInfo{Detail: detail, StackEntries: stack}
+	return e
+}
+
+// WithDetail appends a typed payload
+func (e *APIError) WithDetail(typ string, value interface{}) *APIError {
+	e.Details = append(e.Details, Detail{Type: typ, Value: value})
+	return e
+}
+
+// Problem is the RFC 7807 rendering of APIError, fields other than the
+// standard ones are extension members
+type Problem struct {
+	// URI reference identifying the problem type
+	Type string `json:"type"`
+	// Summary of the problem type
+	Title string `json:"title"`
+	// HTTP status code
+	Status int `json:"status"`
+	// Explanation of this occurrence
+	Detail string `json:"detail,omitempty"`
+	// URI reference of this occurrence
+	Instance   string           `json:"instance,omitempty"`
+	Code       int              `json:"code"`
+	TraceId    string           `json:"traceID,omitempty"`
+	Violations []FieldViolation `json:"violations,omitempty"`
+	RetryAfter int              `json:"retryAfter,omitempty"`
+	Debug      *DebugInfo       `json:"debug,omitempty"`
+	Details    []Detail         `json:"details,omitempty"`
+}
+
+// ToProblem converts error to problem of the request instance
+func (e *APIError) ToProblem(instance string) *Problem {
+	status := ToHttpCode(e)
+	return &Problem{
+		Type:       "about:blank",
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     e.Message,
+		Instance:   instance,
+		Code:       e.Code,
+		TraceId:    e.TraceId,
+		Violations: e.Violations,
+		RetryAfter: e.RetryAfter,
+		Debug:      e.Debug,
+		Details:    e.Details,
+	}
+}
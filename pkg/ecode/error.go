@@ -1,14 +1,17 @@
 package ecode
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
 )
 
 const (
-	BadRequestCode  = 400
-	ServerErrorCode = 500
+	BadRequestCode      = 400
+	TooManyRequestsCode = 429
+	ServerErrorCode     = 500
 )
 
 // APIError describe the error message
@@ -19,6 +22,17 @@ type APIError struct {
 	Message string `json:"message"`
 	// Trace ID
 	TraceId string `json:"traceID,omitempty"`
+	// Invalid fields of request
+	Violations []FieldViolation `json:"violations,omitempty"`
+	// Seconds to wait before retry
+	RetryAfter int `json:"retryAfter,omitempty"`
+	// Debug information, only responded in debug mode
+	Debug *DebugInfo `json:"debug,omitempty"`
+	// Typed payloads
+	Details []Detail `json:"details,omitempty"`
+
+	// internal error kept for logging, never responded
+	cause error
 }
 
 type arr2d [][]int
@@ -44,6 +58,31 @@ func Errorf(code int, format string, args ...interface{}) error {
 	return &APIError{Code: code, Message: fmt.Sprintf(format, args...)}
 }
 
+// Wrap returns error exposing only code and message, err is kept as the internal cause
+func Wrap(err error, code int, message string) *APIError {
+	return &APIError{Code: code, Message: message, cause: err}
+}
+
+// WithCause keeps err as the internal cause which is logged but not responded
+func (e *APIError) WithCause(err error) *APIError {
+	e.cause = err
+	return e
+}
+
+// Unwrap returns the internal cause
+func (e *APIError) Unwrap() error {
+	return e.cause
+}
+
+// As finds the first APIError in the chain of err
+func As(err error) (*APIError, bool) {
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		return apiError, true
+	}
+	return nil, false
+}
+
 // SetSysErrorCode 设置系统错误的错误码，二维数组中每个元素代表一个区间
 /* 使用whitelist-blacklist即差集来判断是否系统错误， 示例
 sys_err:
@@ -90,7 +129,7 @@ func ToErrorCode(err error) (string, string, bool) {
 	if err == nil {
 		return "0", "OK", false
 	}
-	apiError, ok := err.(*APIError)
+	apiError, ok := As(err)
 	if !ok {
 		return fmt.Sprint(ServerErrorCode), "SysErr", true
 	}
@@ -104,18 +143,41 @@ func ToErrorCode(err error) (string, string, bool) {
 	return strCode, "UsrErr", false
 }
 
-// ToHttpCode 将error转成http错误码(200/400/500)
+// RegisterHttpCode maps error code to http status, like business code 40401 to 404
+func RegisterHttpCode(code, status int) {
+	httpCodesMu.Lock()
+	defer httpCodesMu.Unlock()
+	httpCodes[code] = status
+}
+
+var (
+	httpCodesMu sync.RWMutex
+	httpCodes   = map[int]int{}
+)
+
+// ToHttpCode 将error转成http错误码
+// 优先使用RegisterHttpCode注册的映射，其次错误码本身是合法的http错误状态码(4xx/5xx)时直接使用，
+// 否则根据SetSysErrorCode区分系统错误(500)和用户错误(400)
 func ToHttpCode(err error) int {
 	if err == nil {
 		return http.StatusOK
 	}
-	apiError, ok := err.(*APIError)
+	apiError, ok := As(err)
 	if !ok {
 		return http.StatusInternalServerError
 	}
 	if apiError == nil {
 		return http.StatusOK
 	}
+	httpCodesMu.RLock()
+	status, ok := httpCodes[apiError.Code]
+	httpCodesMu.RUnlock()
+	if ok {
+		return status
+	}
+	if 400 <= apiError.Code && apiError.Code < 600 {
+		return apiError.Code
+	}
 	if checkSysError(apiError.Code) {
 		return http.StatusInternalServerError
 	}
@@ -1,6 +1,8 @@
 package ecode
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -57,3 +59,43 @@ func TestStatusCode(t *testing.T) {
 	assert.Equal(t, "SysErr", st)
 	assert.Equal(t, true, isSys)
 }
+
+func TestHttpCode(t *testing.T) {
+	assert.Equal(t, 200, ToHttpCode(nil))
+	assert.Equal(t, 429, ToHttpCode(Errorf(TooManyRequestsCode, "slow down")))
+	assert.Equal(t, 500, ToHttpCode(fmt.Errorf("unknown")))
+	assert.Equal(t, 404, ToHttpCode(Errorf(404, "not found")))
+	assert.Equal(t, 503, ToHttpCode(Errorf(503, "unavailable")))
+
+	// restore the global settings for other tests
+	sysError := checkSysError
+	t.Cleanup(func() {
+		checkSysError = sysError
+		httpCodesMu.Lock()
+		delete(httpCodes, 40401)
+		httpCodesMu.Unlock()
+	})
+	SetSysErrorCode(nil, [][]int{{4000, 4999}, {40000, 49999}})
+	assert.Equal(t, 400, ToHttpCode(Errorf(40401, "user not found")))
+	assert.Equal(t, 500, ToHttpCode(Errorf(10001, "db error")))
+	assert.Equal(t, 400, ToHttpCode(Errorf(4001, "bad name")))
+	RegisterHttpCode(40401, 404)
+	assert.Equal(t, 404, ToHttpCode(Errorf(40401, "user not found")))
+}
+
+func TestWrap(t *testing.T) {
+	cause := fmt.Errorf("connection refused")
+	err := fmt.Errorf("load user: %w", Wrap(cause, 404, "user not found"))
+
+	apiErr, ok := As(err)
+	assert.True(t, ok)
+	assert.Equal(t, "user not found", apiErr.Message)
+	assert.True(t, errors.Is(err, cause))
+	assert.Equal(t, 404, ToHttpCode(err))
+	code, _, _ := ToErrorCode(err)
+	assert.Equal(t, "404", code)
+
+	_, ok = As(cause)
+	assert.False(t, ok)
+	assert.Equal(t, cause, (&APIError{Code: 500}).WithCause(cause).Unwrap())
+}
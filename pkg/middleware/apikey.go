@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/valyala/fasthttp"
+)
+
+// APIKeyConfig configures static API keys
+type APIKeyConfig struct {
+	// header, query or cookie, default is header
+	In string
+	// Name of header, query argument or cookie, default is X-API-Key
+	Name string
+	// Keys maps API key to its subject
+	Keys map[string]string
+	// Scopes granted to the subject
+	Scopes map[string][]string
+}
+
+type apiKeyAuth struct {
+	name string
+	cfg  APIKeyConfig
+}
+
+// NewAPIKeyAuth creates authenticator of static API keys
+func NewAPIKeyAuth(name string, cfg APIKeyConfig) Authenticator {
+	if cfg.In == "" {
+		cfg.In = "header"
+	}
+	if cfg.Name == "" {
+		cfg.Name = "X-API-Key"
+	}
+	return &apiKeyAuth{name: name, cfg: cfg}
+}
+
+func (a *apiKeyAuth) Name() string {
+	return a.name
+}
+
+func (a *apiKeyAuth) Scheme() SecurityScheme {
+	return SecurityScheme{Type: "apiKey", In: a.cfg.In, Name: a.cfg.Name}
+}
+
+func (a *apiKeyAuth) Authenticate(ctx context.Context, fastReq *fasthttp.RequestCtx) (*Principal, error) {
+	var key []byte
+	switch a.cfg.In {
+	case "query":
+		key = fastReq.QueryArgs().Peek(a.cfg.Name)
+	case "cookie":
+		key = fastReq.Request.Header.Cookie(a.cfg.Name)
+	default:
+		key = fastReq.Request.Header.Peek(a.cfg.Name)
+	}
+	if len(key) == 0 {
+		return nil, nil
+	}
+	for k, subject := range a.cfg.Keys {
+		if subtle.ConstantTimeCompare([]byte(k), key) == 1 {
+			return &Principal{Subject: subject, Scheme: a.name, Scopes: a.cfg.Scopes[subject]}, nil
+		}
+	}
+	return nil, errors.New("invalid API key")
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/valyala/fasthttp"
+)
+
+// Principal is the identity authenticated from request
+type Principal struct {
+	// Subject like user id or the owner of API key
+	Subject string
+	// Name of security scheme authenticated the request
+	Scheme string
+	Scopes []string
+	// Claims of JWT, nil for other schemes
+	Claims map[string]interface{}
+}
+
+// HasScopes returns whether the principal is granted all of the scopes
+func (p *Principal) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		found := false
+		for _, s := range p.Scopes {
+			if s == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// SecurityScheme describes how credential is passed, it is documented
+// in the components of OpenAPI document
+type SecurityScheme struct {
+	// apiKey or http
+	Type string
+	// Scheme of http type like bearer or basic
+	Scheme       string
+	BearerFormat string
+	// Location and name of apiKey type, In is header, query or cookie
+	In          string
+	Name        string
+	Description string
+}
+
+// Authenticator verifies credential of a security scheme
+type Authenticator interface {
+	// Name of security scheme referred by MethodOptions.Security
+	Name() string
+	Scheme() SecurityScheme
+	// Authenticate returns nil principal and nil error if credential is absent,
+	// error is returned if credential is invalid
+	Authenticate(ctx context.Context, fastReq *fasthttp.RequestCtx) (*Principal, error)
+}
+
+type authenticatorFunc struct {
+	name   string
+	scheme SecurityScheme
+	fn     func(ctx context.Context, fastReq *fasthttp.RequestCtx) (*Principal, error)
+}
+
+// NewAuthenticator creates authenticator of scheme by the verify function
+func NewAuthenticator(name string, scheme SecurityScheme, fn func(ctx context.Context, fastReq *fasthttp.RequestCtx) (*Principal, error)) Authenticator {
+	return &authenticatorFunc{name: name, scheme: scheme, fn: fn}
+}
+
+func (a *authenticatorFunc) Name() string           { return a.name }
+func (a *authenticatorFunc) Scheme() SecurityScheme { return a.scheme }
+func (a *authenticatorFunc) Authenticate(ctx context.Context, fastReq *fasthttp.RequestCtx) (*Principal, error) {
+	return a.fn(ctx, fastReq)
+}
+
+// ParseSecurity splits security requirement like "jwt:read,write" into scheme name and scopes
+func ParseSecurity(security string) (string, []string) {
+	name, scopes, found := strings.Cut(security, ":")
+	if !found || scopes == "" {
+		return name, nil
+	}
+	return name, strings.Split(scopes, ",")
+}
+
+type principalKey struct{}
+
+// GetPrincipal returns the authenticated principal, nil if the request is anonymous
+func GetPrincipal(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Authenticate returns middleware authenticating request by the authenticators.
+//
+// The security requirements of method are read from CallInfo, any of them
+// satisfied is accepted, 401 is returned if no credential is valid and 403 if
+// the principal lacks required scopes. Methods without requirements are
+// served anonymously, the principal of valid credential is still set and
+// invalid credentials are ignored. Requirements naming a scheme not in
+// authenticators fail with 500 error.
+func Authenticate(authenticators ...Authenticator) Middleware {
+	byName := map[string]Authenticator{}
+	for _, a := range authenticators {
+		byName[a.Name()] = a
+	}
+	return func(ctx context.Context, fastReq *fasthttp.RequestCtx, method MethodFunc, req, rsp interface{}) error {
+		var security []string
+		if info := GetCallInfo(ctx); info != nil {
+			security = info.Security
+		}
+		if len(security) == 0 {
+			for _, a := range authenticators {
+				p, err := a.Authenticate(ctx, fastReq)
+				if err == nil && p != nil {
+					fastReq.SetUserValue(principalKey{}, p)
+					break
+				}
+			}
+			return method(ctx, req, rsp)
+		}
+
+		var lastErr *ecode.APIError
+		var challenge Authenticator
+		for _, requirement := range security {
+			name, scopes := ParseSecurity(requirement)
+			a, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("security scheme %s is not registered in Authenticate", name)
+			}
+			if challenge == nil {
+				challenge = a
+			}
+			p, err := a.Authenticate(ctx, fastReq)
+			if err != nil {
+				lastErr = unauthorized(fastReq, a, err.Error())
+				continue
+			}
+			if p == nil {
+				continue
+			}
+			if p.Scheme == "" {
+				p.Scheme = name
+			}
+			if !p.HasScopes(scopes...) {
+				lastErr = &ecode.APIError{Code: 403, Message: "Insufficient scope, required: " + strings.Join(scopes, " ")}
+				continue
+			}
+			fastReq.SetUserValue(principalKey{}, p)
+			return method(ctx, req, rsp)
+		}
+		if lastErr != nil {
+			return lastErr
+		}
+		return unauthorized(fastReq, challenge, "Authentication required")
+	}
+}
+
+// unauthorized returns 401 error with challenge of http scheme
+func unauthorized(fastReq *fasthttp.RequestCtx, a Authenticator, message string) *ecode.APIError {
+	if a != nil {
+		if scheme := a.Scheme(); scheme.Type == "http" && scheme.Scheme != "" {
+			fastReq.Response.Header.Set("WWW-Authenticate", strings.ToUpper(scheme.Scheme[:1])+scheme.Scheme[1:])
+		}
+	}
+	return &ecode.APIError{Code: 401, Message: message}
+}
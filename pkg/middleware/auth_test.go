@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestAuthenticate(t *testing.T) {
+	secret := []byte("secret")
+	jwtAuth, err := NewJWTAuth("jwt", JWTConfig{Secret: secret, Issuer: "goapi"})
+	assert.Nil(t, err)
+	keyAuth := NewAPIKeyAuth("key", APIKeyConfig{Keys: map[string]string{"k1": "robot"}})
+	auth := Authenticate(jwtAuth, keyAuth)
+
+	sign := func(claims jwt.MapClaims) string {
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		return token
+	}
+	call := func(security []string, header, value string) (*Principal, error) {
+		fastReq := &fasthttp.RequestCtx{}
+		if header != "" {
+			fastReq.Request.Header.Set(header, value)
+		}
+		SetCallInfo(fastReq, &CallInfo{Security: security})
+		var principal *Principal
+		err := auth(fastReq, fastReq, func(ctx context.Context, req, rsp interface{}) error {
+			principal = GetPrincipal(ctx)
+			return nil
+		}, nil, nil)
+		return principal, err
+	}
+	code := func(err error) int {
+		if e, ok := err.(*ecode.APIError); ok {
+			return e.Code
+		}
+		return 0
+	}
+
+	p, err := call(nil, "", "")
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+
+	_, err = call([]string{"jwt"}, "", "")
+	assert.Equal(t, 401, code(err))
+
+	token := sign(jwt.MapClaims{"sub": "alice", "iss": "goapi", "scope": "read write", "exp": time.Now().Add(time.Hour).Unix()})
+	p, err = call([]string{"jwt:read"}, "Authorization", "Bearer "+token)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", p.Subject)
+	assert.Equal(t, []string{"read", "write"}, p.Scopes)
+
+	_, err = call([]string{"jwt:admin"}, "Authorization", "Bearer "+token)
+	assert.Equal(t, 403, code(err))
+
+	expired := sign(jwt.MapClaims{"sub": "alice", "iss": "goapi", "exp": time.Now().Add(-time.Hour).Unix()})
+	_, err = call([]string{"jwt"}, "Authorization", "Bearer "+expired)
+	assert.Equal(t, 401, code(err))
+
+	p, err = call([]string{"jwt", "key"}, "X-API-Key", "k1")
+	assert.Nil(t, err)
+	assert.Equal(t, "robot", p.Subject)
+
+	_, err = call([]string{"key"}, "X-API-Key", "k2")
+	assert.Equal(t, 401, code(err))
+
+	// invalid credentials are ignored by methods without requirements
+	p, err = call(nil, "Authorization", "Bearer "+expired)
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+	p, err = call(nil, "X-API-Key", "k1")
+	assert.Nil(t, err)
+	assert.Equal(t, "robot", p.Subject)
+
+	_, err = call([]string{"oauth"}, "X-API-Key", "k1")
+	assert.EqualError(t, err, "security scheme oauth is not registered in Authenticate")
+}
+
+func TestJWKSFile(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	encode := func(bs []byte) string { return base64.RawURLEncoding.EncodeToString(bs) }
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"EC","kid":"k1","use":"sig","crv":"P-256","x":"%s","y":"%s"}]}`,
+		encode(key.X.FillBytes(make([]byte, 32))), encode(key.Y.FillBytes(make([]byte, 32))))
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	assert.Nil(t, os.WriteFile(file, []byte(jwks), 0600))
+
+	a, err := NewJWTAuth("jwt", JWTConfig{JWKSFile: file})
+	assert.Nil(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "bob"})
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(key)
+	assert.Nil(t, err)
+
+	fastReq := &fasthttp.RequestCtx{}
+	fastReq.Request.Header.Set("Authorization", "Bearer "+signed)
+	p, err := a.Authenticate(context.Background(), fastReq)
+	assert.Nil(t, err)
+	assert.Equal(t, "bob", p.Subject)
+
+	// HS256 signed with public key material must be rejected
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "eve"}).SignedString([]byte(jwks))
+	fastReq.Request.Header.Set("Authorization", "Bearer "+forged)
+	_, err = a.Authenticate(context.Background(), fastReq)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/valyala/fasthttp"
+)
+
+var defaultCORSMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+
+// CORSConfig configures cross-origin resource sharing
+type CORSConfig struct {
+	// Origins allowed like "https://example.com", "*" allows any origin and
+	// "*" inside an origin matches a part of host, like "https://*.example.com"
+	AllowOrigins []string
+	// Regular expressions of allowed origins, like `^https://[a-z]+\.example\.com$`
+	AllowOriginRegexps []string
+	// Custom check of origin, used if origin is not allowed by the lists above
+	AllowOriginFunc func(origin string) bool
+	// Methods allowed in preflight, default is GET, HEAD, POST, PUT, PATCH and DELETE
+	AllowMethods []string
+	// Headers allowed in preflight, the requested headers are allowed if empty
+	AllowHeaders []string
+	// Response headers readable by browser
+	ExposeHeaders []string
+	// Whether cookies and authorization are allowed, the request origin is
+	// returned instead of "*" if credentials are allowed
+	AllowCredentials bool
+	// How long the preflight result can be cached by browser, zero means not sent
+	MaxAge time.Duration
+}
+
+// CORSPolicy checks origin and writes CORS headers by config
+type CORSPolicy struct {
+	cfg       CORSConfig
+	anyOrigin bool
+	origins   map[string]bool
+	patterns  []*regexp.Regexp
+
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	maxAge        string
+}
+
+// NewCORSPolicy compiles config, it panics if any origin regexp is invalid
+func NewCORSPolicy(cfg CORSConfig) *CORSPolicy {
+	p := &CORSPolicy{cfg: cfg, origins: map[string]bool{}}
+	for _, origin := range cfg.AllowOrigins {
+		origin = strings.ToLower(origin)
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			pattern := strings.ReplaceAll(regexp.QuoteMeta(origin), `\*`, `[^/]*`)
+			p.patterns = append(p.patterns, regexp.MustCompile("^"+pattern+"$"))
+		default:
+			p.origins[origin] = true
+		}
+	}
+	for _, expr := range cfg.AllowOriginRegexps {
+		p.patterns = append(p.patterns, regexp.MustCompile(expr))
+	}
+	methods := cfg.AllowMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	p.allowMethods = strings.ToUpper(strings.Join(methods, ", "))
+	p.allowHeaders = strings.Join(cfg.AllowHeaders, ", ")
+	p.exposeHeaders = strings.Join(cfg.ExposeHeaders, ", ")
+	if cfg.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(cfg.MaxAge / time.Second))
+	}
+	return p
+}
+
+// CORS returns middleware handling cross-origin requests by config.
+// Preflight requests are answered without calling the method.
+func CORS(cfg CORSConfig) Middleware {
+	return NewCORSPolicy(cfg).Middleware
+}
+
+// AllowsOrigin returns whether origin is allowed
+func (p *CORSPolicy) AllowsOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if p.anyOrigin {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if p.origins[lower] {
+		return true
+	}
+	for _, re := range p.patterns {
+		if re.MatchString(lower) {
+			return true
+		}
+	}
+	return p.cfg.AllowOriginFunc != nil && p.cfg.AllowOriginFunc(origin)
+}
+
+// Middleware writes CORS headers for allowed origins and answers preflight requests
+func (p *CORSPolicy) Middleware(ctx context.Context, fastReq *fasthttp.RequestCtx, method MethodFunc, req, rsp interface{}) error {
+	header := &fastReq.Response.Header
+	origin := string(fastReq.Request.Header.Peek("Origin"))
+	if origin == "" {
+		return method(ctx, req, rsp)
+	}
+	addVary(header, "Origin")
+
+	preflight := fastReq.IsOptions() && len(fastReq.Request.Header.Peek("Access-Control-Request-Method")) > 0
+	if !p.AllowsOrigin(origin) {
+		if preflight {
+			return &ecode.APIError{Code: 403, Message: "Origin " + origin + " is not allowed"}
+		}
+		return method(ctx, req, rsp)
+	}
+
+	if !preflight {
+		p.exposeTo(header, origin)
+		return method(ctx, req, rsp)
+	}
+	p.allowOrigin(header, origin)
+
+	addVary(header, "Access-Control-Request-Method")
+	addVary(header, "Access-Control-Request-Headers")
+	header.Set("Access-Control-Allow-Methods", p.allowMethods)
+	if p.allowHeaders != "" {
+		header.Set("Access-Control-Allow-Headers", p.allowHeaders)
+	} else if requested := fastReq.Request.Header.Peek("Access-Control-Request-Headers"); len(requested) > 0 {
+		header.SetBytesV("Access-Control-Allow-Headers", requested)
+	}
+	if p.maxAge != "" {
+		header.Set("Access-Control-Max-Age", p.maxAge)
+	}
+	fastReq.SetStatusCode(fasthttp.StatusNoContent)
+	return nil
+}
+
+// WriteHeaders writes CORS headers of actual request if origin is allowed and the
+// headers are not written yet, it's used for responses written without Middleware
+// like errors of routing and decoding
+func (p *CORSPolicy) WriteHeaders(fastReq *fasthttp.RequestCtx) {
+	header := &fastReq.Response.Header
+	origin := string(fastReq.Request.Header.Peek("Origin"))
+	if origin == "" || len(header.Peek("Access-Control-Allow-Origin")) > 0 {
+		return
+	}
+	addVary(header, "Origin")
+	if p.AllowsOrigin(origin) {
+		p.exposeTo(header, origin)
+	}
+}
+
+// allowOrigin writes headers of allowed origin
+func (p *CORSPolicy) allowOrigin(header *fasthttp.ResponseHeader, origin string) {
+	if p.anyOrigin && !p.cfg.AllowCredentials {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.cfg.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// exposeTo writes headers of actual request from allowed origin
+func (p *CORSPolicy) exposeTo(header *fasthttp.ResponseHeader, origin string) {
+	p.allowOrigin(header, origin)
+	if p.exposeHeaders != "" {
+		header.Set("Access-Control-Expose-Headers", p.exposeHeaders)
+	}
+}
+
+// addVary adds value to Vary header unless it's added
+func addVary(header *fasthttp.ResponseHeader, value string) {
+	for _, v := range header.PeekAll("Vary") {
+		if strings.EqualFold(string(v), value) {
+			return
+		}
+	}
+	header.Add("Vary", value)
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestCORS(t *testing.T) {
+	p := NewCORSPolicy(CORSConfig{
+		AllowOrigins:       []string{"https://example.com", "https://*.example.org"},
+		AllowOriginRegexps: []string{`^http://localhost:\d+$`},
+		ExposeHeaders:      []string{"X-Request-Id"},
+		AllowCredentials:   true,
+		MaxAge:             time.Hour,
+	})
+	assert.True(t, p.AllowsOrigin("https://example.com"))
+	assert.True(t, p.AllowsOrigin("https://api.example.org"))
+	assert.True(t, p.AllowsOrigin("http://localhost:3000"))
+	assert.False(t, p.AllowsOrigin("https://evil.com"))
+	assert.False(t, p.AllowsOrigin("https://example.org.evil.com"))
+
+	called := false
+	method := func(context.Context, interface{}, interface{}) error {
+		called = true
+		return nil
+	}
+
+	fastReq := &fasthttp.RequestCtx{}
+	fastReq.Request.Header.SetMethod("OPTIONS")
+	fastReq.Request.Header.Set("Origin", "https://api.example.org")
+	fastReq.Request.Header.Set("Access-Control-Request-Method", "PUT")
+	fastReq.Request.Header.Set("Access-Control-Request-Headers", "content-type")
+	assert.Nil(t, p.Middleware(fastReq, fastReq, method, nil, nil))
+	assert.False(t, called)
+	assert.Equal(t, fasthttp.StatusNoContent, fastReq.Response.StatusCode())
+	assert.Equal(t, "https://api.example.org", string(fastReq.Response.Header.Peek("Access-Control-Allow-Origin")))
+	assert.Equal(t, "content-type", string(fastReq.Response.Header.Peek("Access-Control-Allow-Headers")))
+	assert.Equal(t, "3600", string(fastReq.Response.Header.Peek("Access-Control-Max-Age")))
+
+	fastReq = &fasthttp.RequestCtx{}
+	fastReq.Request.Header.Set("Origin", "https://evil.com")
+	assert.Nil(t, p.Middleware(fastReq, fastReq, method, nil, nil))
+	assert.True(t, called)
+	assert.Empty(t, fastReq.Response.Header.Peek("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", string(fastReq.Response.Header.Peek("Vary")))
+}
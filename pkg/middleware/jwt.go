@@ -0,0 +1,296 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/valyala/fasthttp"
+)
+
+// jwksMinRefresh limits refetching JWKS URL for unknown key id
+var jwksMinRefresh = time.Minute
+
+// JWTConfig configures JWT bearer authentication, one of Secret, PublicKey,
+// JWKSFile and JWKSURL should be set
+type JWTConfig struct {
+	// Secret of HS256, HS384 and HS512
+	Secret []byte
+	// *rsa.PublicKey or *ecdsa.PublicKey of RS, PS and ES algorithms
+	PublicKey interface{}
+	// JSON Web Key Set loaded from local file or URL, keys are selected by kid
+	JWKSFile string
+	JWKSURL  string
+	// Algorithms accepted, default is HS* for Secret and RS*, PS*, ES* for public keys
+	Algorithms []string
+	// Claims validated if not empty
+	Issuer   string
+	Audience string
+	// Clock skew allowed for exp, nbf and iat
+	Leeway time.Duration
+	// Claim of scopes, either space separated string or array, default is scope
+	ScopeClaim string
+}
+
+type jwtAuth struct {
+	name   string
+	cfg    JWTConfig
+	parser *jwt.Parser
+
+	mu        sync.RWMutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// NewJWTAuth creates authenticator of JWT in Authorization bearer header
+func NewJWTAuth(name string, cfg JWTConfig) (Authenticator, error) {
+	a := &jwtAuth{name: name, cfg: cfg}
+	if a.cfg.ScopeClaim == "" {
+		a.cfg.ScopeClaim = "scope"
+	}
+	algs := cfg.Algorithms
+	switch {
+	case len(cfg.Secret) > 0:
+		if len(algs) == 0 {
+			algs = []string{"HS256", "HS384", "HS512"}
+		}
+	case cfg.PublicKey != nil:
+		switch cfg.PublicKey.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+		default:
+			return nil, fmt.Errorf("unsupported JWT public key %T", cfg.PublicKey)
+		}
+	case cfg.JWKSFile != "":
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("read JWKS: %v", err)
+		}
+		if a.keys, err = parseJWKS(data); err != nil {
+			return nil, err
+		}
+	case cfg.JWKSURL != "":
+		if err := a.fetchJWKS(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("key of JWT %s is not configured", name)
+	}
+	if len(algs) == 0 {
+		algs = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(algs), jwt.WithLeeway(cfg.Leeway)}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+	return a, nil
+}
+
+func (a *jwtAuth) Name() string {
+	return a.name
+}
+
+func (a *jwtAuth) Scheme() SecurityScheme {
+	return SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+}
+
+func (a *jwtAuth) Authenticate(ctx context.Context, fastReq *fasthttp.RequestCtx) (*Principal, error) {
+	auth := fastReq.Request.Header.Peek("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(string(auth[:7]), "bearer ") {
+		return nil, nil
+	}
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(string(bytes.TrimSpace(auth[7:])), claims, a.keyFunc); err != nil {
+		return nil, fmt.Errorf("invalid token: %v", err)
+	}
+	p := &Principal{Scheme: a.name, Claims: claims}
+	p.Subject, _ = claims.GetSubject()
+	switch scopes := claims[a.cfg.ScopeClaim].(type) {
+	case string:
+		p.Scopes = strings.Fields(scopes)
+	case []interface{}:
+		for _, s := range scopes {
+			if s, ok := s.(string); ok {
+				p.Scopes = append(p.Scopes, s)
+			}
+		}
+	}
+	return p, nil
+}
+
+func (a *jwtAuth) keyFunc(token *jwt.Token) (interface{}, error) {
+	if len(a.cfg.Secret) > 0 {
+		return a.cfg.Secret, nil
+	}
+	if a.cfg.PublicKey != nil {
+		return a.cfg.PublicKey, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := a.lookupKey(kid); ok {
+		return key, nil
+	}
+	if a.cfg.JWKSURL != "" && a.shouldRefresh() {
+		if err := a.fetchJWKS(); err != nil {
+			return nil, err
+		}
+		if key, ok := a.lookupKey(kid); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key %q not found", kid)
+}
+
+// lookupKey returns key by kid, the only key is used if kid is empty
+func (a *jwtAuth) lookupKey(kid string) (interface{}, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if kid == "" && len(a.keys) == 1 {
+		for _, key := range a.keys {
+			return key, true
+		}
+	}
+	key, ok := a.keys[kid]
+	return key, ok
+}
+
+func (a *jwtAuth) shouldRefresh() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return time.Since(a.fetchedAt) >= jwksMinRefresh
+}
+
+func (a *jwtAuth) fetchJWKS() error {
+	a.mu.Lock()
+	a.fetchedAt = time.Now()
+	a.mu.Unlock()
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	rsp, err := client.Get(a.cfg.JWKSURL)
+	if err != nil {
+		return fmt.Errorf("fetch JWKS: %v", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch JWKS: status %d", rsp.StatusCode)
+	}
+	data, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return fmt.Errorf("fetch JWKS: %v", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.keys = keys
+	a.mu.Unlock()
+	return nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS parses RSA and EC public keys of JSON Web Key Set by kid, keys not for signature are skipped
+func parseJWKS(data []byte) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse JWKS: %v", err)
+	}
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key interface{}
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsaKey()
+		case "EC":
+			key, err = k.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse JWK %s: %v", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no signature key found in JWKS")
+	}
+	return keys, nil
+}
+
+func (k *jsonWebKey) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k *jsonWebKey) ecKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+	}
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	bs, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(bs), nil
+}
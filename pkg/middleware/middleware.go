@@ -2,9 +2,43 @@ package middleware
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/valyala/fasthttp"
 )
 
 type MethodFunc func(context.Context, interface{}, interface{}) error
 type Middleware func(ctx context.Context, fastReq *fasthttp.RequestCtx, method MethodFunc, req, rsp interface{}) error
+
+// CallInfo describes the route being called, it is shared by all requests of the route
+type CallInfo struct {
+	// Service and method name of typed handler, empty for raw http handler
+	ServiceName string
+	MethodName  string
+	// Route pattern registered, like /api/users/{id}
+	Route string
+	// HTTP method registered, empty if raw http handler serves any method
+	Verb string
+	// Whether the method is a websocket stream
+	IsStream bool
+	// Types of request and response, nil for raw http handler
+	ReqType reflect.Type
+	RspType reflect.Type
+	// Operation ID in OpenAPI document
+	OperationID string
+	// Security schemes accepted by the method, empty if authentication is not required
+	Security []string
+}
+
+type callInfoKey struct{}
+
+// SetCallInfo attaches the call info to request
+func SetCallInfo(fastReq *fasthttp.RequestCtx, info *CallInfo) {
+	fastReq.SetUserValue(callInfoKey{}, info)
+}
+
+// GetCallInfo returns the call info of context passed to middleware and handler
+func GetCallInfo(ctx context.Context) *CallInfo {
+	info, _ := ctx.Value(callInfoKey{}).(*CallInfo)
+	return info
+}
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/valyala/fasthttp"
+)
+
+// rateLimitSweepInterval is the interval to remove idle buckets
+var rateLimitSweepInterval = time.Minute
+
+// KeyFunc extracts the key of client to limit, empty key is not limited
+type KeyFunc func(ctx context.Context, fastReq *fasthttp.RequestCtx) string
+
+// KeyByIP limits by remote IP, X-Forwarded-For is not trusted
+func KeyByIP(ctx context.Context, fastReq *fasthttp.RequestCtx) string {
+	return fastReq.RemoteIP().String()
+}
+
+// KeyByHeader limits by value of header like X-API-Key
+func KeyByHeader(name string) KeyFunc {
+	return func(ctx context.Context, fastReq *fasthttp.RequestCtx) string {
+		return string(fastReq.Request.Header.Peek(name))
+	}
+}
+
+// KeyByPrincipal limits by subject of authenticated principal, falls back to remote IP
+func KeyByPrincipal(ctx context.Context, fastReq *fasthttp.RequestCtx) string {
+	if p := GetPrincipal(ctx); p != nil {
+		return p.Scheme + ":" + p.Subject
+	}
+	return KeyByIP(ctx, fastReq)
+}
+
+// RateLimitConfig configures token bucket rate limiting
+type RateLimitConfig struct {
+	// Tokens added per second
+	Rate float64
+	// Capacity of bucket, default is the ceiling of Rate
+	Burst int
+	// Key of bucket, default is KeyByIP
+	Key KeyFunc
+	// Limit each method separately instead of sharing bucket across methods
+	PerMethod bool
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimiter struct {
+	cfg       RateLimitConfig
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	sweptAt   time.Time
+	fullAfter time.Duration
+}
+
+// RateLimit returns middleware limiting requests by token bucket of each key,
+// 429 is returned with RetryAfter if the bucket is empty.
+// Use it in MethodOptions.Middlewares for limits of a single method.
+func RateLimit(cfg RateLimitConfig) Middleware {
+	if cfg.Rate <= 0 {
+		panic("rate of RateLimit should be positive")
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = int(math.Ceil(cfg.Rate))
+	}
+	if cfg.Key == nil {
+		cfg.Key = KeyByIP
+	}
+	l := &rateLimiter{
+		cfg:       cfg,
+		buckets:   map[string]*bucket{},
+		sweptAt:   time.Now(),
+		fullAfter: time.Duration(float64(cfg.Burst) / cfg.Rate * float64(time.Second)),
+	}
+	return l.middleware
+}
+
+func (l *rateLimiter) middleware(ctx context.Context, fastReq *fasthttp.RequestCtx, method MethodFunc, req, rsp interface{}) error {
+	key := l.cfg.Key(ctx, fastReq)
+	if key == "" {
+		return method(ctx, req, rsp)
+	}
+	if l.cfg.PerMethod {
+		if info := GetCallInfo(ctx); info != nil {
+			key = info.Verb + " " + info.Route + " " + key
+		}
+	}
+
+	allowed, remaining, wait := l.take(key, time.Now())
+	header := &fastReq.Response.Header
+	header.Set("RateLimit-Limit", strconv.Itoa(l.cfg.Burst))
+	header.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(time.Duration(float64(l.cfg.Burst-remaining)/l.cfg.Rate*float64(time.Second)))))
+	if !allowed {
+		return (&ecode.APIError{Code: ecode.TooManyRequestsCode, Message: "Rate limit exceeded"}).WithRetryAfter(wait)
+	}
+	return method(ctx, req, rsp)
+}
+
+// take consumes a token of key, returns remaining tokens or the duration to wait for next token
+func (l *rateLimiter) take(key string, now time.Time) (bool, int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.sweptAt) >= rateLimitSweepInterval {
+		l.sweep(now)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.cfg.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.cfg.Burst), b.tokens+now.Sub(b.last).Seconds()*l.cfg.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, 0, time.Duration((1 - b.tokens) / l.cfg.Rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// sweep removes buckets which are refilled to full
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.fullAfter {
+			delete(l.buckets, key)
+		}
+	}
+	l.sweptAt = now
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// ConcurrencyConfig configures the limit of in-flight requests
+type ConcurrencyConfig struct {
+	// Requests served at the same time, it should be positive
+	MaxInFlight int
+	// How long a request waits for a slot before rejected, zero rejects immediately
+	QueueTimeout time.Duration
+}
+
+// ConcurrencyLimit returns middleware limiting requests served at the same time,
+// 429 is returned if no slot is available within QueueTimeout.
+func ConcurrencyLimit(cfg ConcurrencyConfig) Middleware {
+	if cfg.MaxInFlight <= 0 {
+		panic("max in-flight of ConcurrencyLimit should be positive")
+	}
+	slots := make(chan struct{}, cfg.MaxInFlight)
+	return func(ctx context.Context, fastReq *fasthttp.RequestCtx, method MethodFunc, req, rsp interface{}) error {
+		select {
+		case slots <- struct{}{}:
+		default:
+			if !waitSlot(ctx, slots, cfg.QueueTimeout) {
+				return (&ecode.APIError{Code: ecode.TooManyRequestsCode, Message: "Too many concurrent requests"}).WithRetryAfter(time.Second)
+			}
+		}
+		defer func() { <-slots }()
+		return method(ctx, req, rsp)
+	}
+}
+
+func waitSlot(ctx context.Context, slots chan struct{}, timeout time.Duration) bool {
+	if timeout <= 0 {
+		return false
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
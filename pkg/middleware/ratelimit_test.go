@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRateLimit(t *testing.T) {
+	l := &rateLimiter{cfg: RateLimitConfig{Rate: 2, Burst: 2}, buckets: map[string]*bucket{}, fullAfter: time.Second}
+	now := time.Now()
+	l.sweptAt = now
+
+	ok, remaining, _ := l.take("a", now)
+	assert.True(t, ok)
+	assert.Equal(t, 1, remaining)
+	ok, _, _ = l.take("a", now)
+	assert.True(t, ok)
+	ok, _, wait := l.take("a", now)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	ok, _, _ = l.take("b", now)
+	assert.True(t, ok)
+
+	ok, _, _ = l.take("a", now.Add(500*time.Millisecond))
+	assert.True(t, ok)
+
+	l.take("b", now.Add(2*time.Minute))
+	assert.Len(t, l.buckets, 1)
+
+	limit := RateLimit(RateLimitConfig{Rate: 1, Key: KeyByHeader("X-API-Key")})
+	fastReq := &fasthttp.RequestCtx{}
+	fastReq.Request.Header.Set("X-API-Key", "k1")
+	noop := func(context.Context, interface{}, interface{}) error { return nil }
+	assert.Nil(t, limit(fastReq, fastReq, noop, nil, nil))
+	err := limit(fastReq, fastReq, noop, nil, nil)
+	assert.Equal(t, ecode.TooManyRequestsCode, err.(*ecode.APIError).Code)
+	assert.Equal(t, 1, err.(*ecode.APIError).RetryAfter)
+	assert.Equal(t, "0", string(fastReq.Response.Header.Peek("RateLimit-Remaining")))
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	limit := ConcurrencyLimit(ConcurrencyConfig{MaxInFlight: 1, QueueTimeout: 10 * time.Millisecond})
+	started := make(chan struct{})
+	release := make(chan struct{})
+	blocking := func(context.Context, interface{}, interface{}) error {
+		close(started)
+		<-release
+		return nil
+	}
+	noop := func(context.Context, interface{}, interface{}) error { return nil }
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		limit(context.Background(), &fasthttp.RequestCtx{}, blocking, nil, nil)
+	}()
+	<-started
+	err := limit(context.Background(), &fasthttp.RequestCtx{}, noop, nil, nil)
+	assert.Equal(t, ecode.TooManyRequestsCode, err.(*ecode.APIError).Code)
+
+	close(release)
+	wg.Wait()
+	assert.Nil(t, limit(context.Background(), &fasthttp.RequestCtx{}, noop, nil, nil))
+
+	assert.PanicsWithValue(t, "max in-flight of ConcurrencyLimit should be positive", func() {
+		ConcurrencyLimit(ConcurrencyConfig{})
+	})
+}
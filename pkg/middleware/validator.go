@@ -2,20 +2,200 @@ package middleware
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/ja"
+	"github.com/go-playground/locales/pt_BR"
+	"github.com/go-playground/locales/ru"
+	"github.com/go-playground/locales/zh"
+	"github.com/go-playground/locales/zh_Hant_TW"
+	ut "github.com/go-playground/universal-translator"
 	validate "github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	ja_translations "github.com/go-playground/validator/v10/translations/ja"
+	pt_BR_translations "github.com/go-playground/validator/v10/translations/pt_BR"
+	ru_translations "github.com/go-playground/validator/v10/translations/ru"
+	zh_translations "github.com/go-playground/validator/v10/translations/zh"
+	zh_tw_translations "github.com/go-playground/validator/v10/translations/zh_tw"
 	"github.com/ottstack/goapi/pkg/ecode"
 	"github.com/valyala/fasthttp"
 )
 
 var validator = validate.New()
 
+var translator *ut.UniversalTranslator
+
+// locale aliases of Accept-Language not matching the locale names
+var localeAliases = map[string]string{
+	"zh_tw":   "zh_Hant_TW",
+	"zh_hk":   "zh_Hant_TW",
+	"zh_hant": "zh_Hant_TW",
+	"zh_cn":   "zh",
+	"zh_hans": "zh",
+	"pt":      "pt_BR",
+}
+
+// builtin translations, the first one is fallback
+var translations = []struct {
+	locale   locales.Translator
+	register func(*validate.Validate, ut.Translator) error
+}{
+	{en.New(), en_translations.RegisterDefaultTranslations},
+	{es.New(), es_translations.RegisterDefaultTranslations},
+	{fr.New(), fr_translations.RegisterDefaultTranslations},
+	{ja.New(), ja_translations.RegisterDefaultTranslations},
+	{pt_BR.New(), pt_BR_translations.RegisterDefaultTranslations},
+	{ru.New(), ru_translations.RegisterDefaultTranslations},
+	{zh.New(), zh_translations.RegisterDefaultTranslations},
+	{zh_Hant_TW.New(), zh_tw_translations.RegisterDefaultTranslations},
+}
+
+func init() {
+	validator.RegisterTagNameFunc(fieldName)
+
+	translator = ut.New(translations[0].locale)
+	for _, d := range translations {
+		translator.AddTranslator(d.locale, true)
+		trans, _ := translator.GetTranslator(d.locale.Locale())
+		if err := d.register(validator, trans); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// fieldName names field in violations like the OpenAPI document, the json name
+// of body fields and the parameter name of bound fields
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "path", "query", "header", "cookie"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return strings.ToLower(field.Name[:1]) + field.Name[1:]
+}
+
+// RegisterValidation adds custom rule of tag, message is used for all locales
+// with {0} as field name and {1} as parameter. It should be called before serving.
+func RegisterValidation(tag string, fn validate.Func, message string) error {
+	if err := validator.RegisterValidation(tag, fn); err != nil {
+		return err
+	}
+	for _, t := range translations {
+		if err := RegisterTranslation(t.locale.Locale(), tag, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterTranslation sets message of tag in locale like "en" or "zh",
+// the message can refer to field name by {0} and parameter by {1}.
+// Error is returned if locale is not one of the builtin translations.
+func RegisterTranslation(locale, tag, message string) error {
+	if alias, ok := localeAliases[strings.ToLower(locale)]; ok {
+		locale = alias
+	}
+	trans, found := translator.GetTranslator(locale)
+	if !found {
+		return fmt.Errorf("translation of locale %s is not supported", locale)
+	}
+	return validator.RegisterTranslation(tag, trans, func(t ut.Translator) error {
+		return t.Add(tag, message, true)
+	}, func(t ut.Translator, fe validate.FieldError) string {
+		msg, err := t.T(tag, fe.Field(), fe.Param())
+		if err != nil {
+			return fe.Error()
+		}
+		return msg
+	})
+}
+
+// RegisterStructValidation adds struct level validation for the types,
+// violations are reported by StructLevel.ReportError
+func RegisterStructValidation(fn validate.StructLevelFunc, types ...interface{}) {
+	validator.RegisterStructValidation(fn, types...)
+}
+
+// findTranslator returns translator of the most preferred language in Accept-Language
+func findTranslator(acceptLanguage string) ut.Translator {
+	type language struct {
+		tag string
+		q   float64
+	}
+	var langs []language
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, _ = strconv.ParseFloat(v, 64)
+		}
+		if tag != "" && tag != "*" && q > 0 {
+			langs = append(langs, language{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].q > langs[j].q
+	})
+	var candidates []string
+	for _, lang := range langs {
+		locale := strings.ToLower(strings.ReplaceAll(lang.tag, "-", "_"))
+		base, _, _ := strings.Cut(locale, "_")
+		for _, l := range []string{locale, base} {
+			if alias, ok := localeAliases[l]; ok {
+				candidates = append(candidates, alias)
+			}
+			candidates = append(candidates, l)
+		}
+	}
+	trans, _ := translator.FindTranslator(candidates...)
+	return trans
+}
+
+// Validator validates request by `validate` tags, 400 error with violations
+// of fields is returned, the messages are translated by Accept-Language.
 func Validator(ctx context.Context, fastReq *fasthttp.RequestCtx, method MethodFunc, req, rsp interface{}) (err error) {
 	if req == nil {
 		return method(ctx, req, rsp)
 	}
 	if err := validator.Struct(req); err != nil {
-		return &ecode.APIError{Code: 400, Message: err.Error()}
+		fieldErrors, ok := err.(validate.ValidationErrors)
+		if !ok {
+			return &ecode.APIError{Code: 400, Message: err.Error()}
+		}
+		trans := findTranslator(string(fastReq.Request.Header.Peek("Accept-Language")))
+		apiErr := &ecode.APIError{Code: 400}
+		messages := make([]string, 0, len(fieldErrors))
+		for _, fe := range fieldErrors {
+			msg := fe.Translate(trans)
+			messages = append(messages, msg)
+			apiErr.Violations = append(apiErr.Violations, ecode.FieldViolation{
+				Field:       fieldPath(fe.Namespace()),
+				Description: msg,
+				Rule:        fe.Tag(),
+				Param:       fe.Param(),
+			})
+		}
+		apiErr.Message = strings.Join(messages, "; ")
+		return apiErr
 	}
 	return method(ctx, req, rsp)
 }
+
+// fieldPath strips the struct name from namespace like "CreateReq.items[0].name"
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	validate "github.com/go-playground/validator/v10"
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type validateItem struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type validateReq struct {
+	ID       int            `path:"id" validate:"min=1"`
+	Nickname string         `json:"nick_name" validate:"max=3"`
+	Items    []validateItem `json:"items" validate:"dive"`
+	Color    string         `json:"color" validate:"omitempty,color3"`
+	Password string         `json:"password"`
+	Confirm  string         `json:"confirm"`
+}
+
+func TestValidator(t *testing.T) {
+	assert.Nil(t, RegisterValidation("color3", func(fl validate.FieldLevel) bool {
+		return len(fl.Field().String()) == 3
+	}, "{0} must be a color of 3 letters"))
+	RegisterStructValidation(func(sl validate.StructLevel) {
+		req := sl.Current().Interface().(validateReq)
+		if req.Password != req.Confirm {
+			sl.ReportError(req.Confirm, "confirm", "Confirm", "eqfield", "password")
+		}
+	}, validateReq{})
+
+	noop := func(context.Context, interface{}, interface{}) error { return nil }
+	req := &validateReq{ID: 0, Nickname: "goapi", Items: []validateItem{{Name: "a"}, {}}, Color: "red!", Password: "a"}
+	fastReq := &fasthttp.RequestCtx{}
+	err := Validator(fastReq, fastReq, noop, req, nil)
+	apiErr := err.(*ecode.APIError)
+	assert.Equal(t, 400, apiErr.Code)
+
+	fields := map[string]ecode.FieldViolation{}
+	for _, v := range apiErr.Violations {
+		fields[v.Field] = v
+	}
+	assert.Equal(t, "min", fields["id"].Rule)
+	assert.Equal(t, "1", fields["id"].Param)
+	assert.Equal(t, "nick_name must be a maximum of 3 characters in length", fields["nick_name"].Description)
+	assert.Equal(t, "required", fields["items[1].name"].Rule)
+	assert.Equal(t, "color must be a color of 3 letters", fields["color"].Description)
+	assert.Equal(t, "eqfield", fields["confirm"].Rule)
+
+	fastReq.Request.Header.Set("Accept-Language", "fr;q=0.5, zh-CN")
+	err = Validator(fastReq, fastReq, noop, req, nil)
+	for _, v := range err.(*ecode.APIError).Violations {
+		if v.Field == "items[1].name" {
+			assert.Equal(t, "name为必填字段", v.Description)
+		}
+	}
+
+	assert.Nil(t, Validator(fastReq, fastReq, noop, &validateReq{ID: 1}, nil))
+}
+
+type evenReq struct {
+	Count int `json:"count" validate:"even"`
+}
+
+func TestRegisterTranslation(t *testing.T) {
+	assert.Nil(t, RegisterValidation("even", func(fl validate.FieldLevel) bool {
+		return fl.Field().Int()%2 == 0
+	}, "{0} must be even"))
+	assert.Nil(t, RegisterTranslation("zh_CN", "even", "{0}必须是偶数"))
+	assert.EqualError(t, RegisterTranslation("de", "even", "{0} muss gerade sein"), "translation of locale de is not supported")
+
+	noop := func(context.Context, interface{}, interface{}) error { return nil }
+	describe := func(acceptLanguage string) string {
+		fastReq := &fasthttp.RequestCtx{}
+		fastReq.Request.Header.Set("Accept-Language", acceptLanguage)
+		err := Validator(fastReq, fastReq, noop, &evenReq{Count: 1}, nil)
+		return err.(*ecode.APIError).Violations[0].Description
+	}
+	assert.Equal(t, "count必须是偶数", describe("zh-CN"))
+	// unsupported locale doesn't replace the English fallback
+	assert.Equal(t, "count must be even", describe("de"))
+	assert.Equal(t, "count must be even", describe(""))
+}
@@ -1,19 +1,87 @@
 package goapi
 
 import (
-	json "github.com/goccy/go-json"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/ottstack/goapi/pkg/coder"
 	"github.com/ottstack/goapi/pkg/ecode"
 	"github.com/valyala/fasthttp"
 )
 
-var encoder = json.Marshal
-var jsonDecoder = json.Unmarshal
+var (
+	errorCodecs     *coder.Set
+	errorCodecsOnce sync.Once
+)
+
+// publicError returns the APIError in chain of err to respond, messages of
+// other errors are redacted and only exposed as debug information in debug mode.
+// Internal errors of 5xx responses are logged.
+func (s *Server) publicError(w *fasthttp.RequestCtx, err error) *ecode.APIError {
+	var public ecode.APIError
+	var internal error
+	if apiErr, ok := ecode.As(err); ok && apiErr != nil {
+		public = *apiErr
+		if apiErr.Unwrap() != nil || error(apiErr) != err {
+			internal = err
+		}
+	} else {
+		public = ecode.APIError{Code: ecode.ServerErrorCode, Message: "Internal server error"}
+		internal = err
+	}
+	if !s.debug {
+		public.Debug = nil
+	} else if internal != nil && public.Debug == nil {
+		public.Debug = &ecode.DebugInfo{Detail: internal.Error()}
+	}
+	if internal != nil && ecode.ToHttpCode(&public) >= 500 {
+		log.Printf("%s %s error: %v", w.Method(), w.Path(), errorChain(internal))
+	}
+	return &public
+}
+
+// errorChain formats err with the causes of APIError which are hidden by Error()
+func errorChain(err error) string {
+	msg := err.Error()
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if apiErr, ok := e.(*ecode.APIError); ok && apiErr.Unwrap() != nil {
+			msg += ": " + apiErr.Unwrap().Error()
+		}
+	}
+	return msg
+}
+
+// writeErrResponse writes error in the negotiated codec, JSON error is rendered as
+// problem details if SERVE_PROBLEM_JSON is set or application/problem+json is accepted
+func (s *Server) writeErrResponse(w *fasthttp.RequestCtx, err error) {
+	apiErr := s.publicError(w, err)
+	// error is always writable, fallback to default codec if Accept is not supported
+	errorCodecsOnce.Do(func() {
+		errorCodecs = coder.For(apiErrorType)
+	})
+	accept := string(w.Request.Header.Peek("Accept"))
+	encoder, mediaType, ok := errorCodecs.NegotiateMediaType(accept)
+	if !ok {
+		encoder = coder.Default
+	}
+	w.Response.SetStatusCode(ecode.ToHttpCode(apiErr))
+	// errors of routing and decoding are written before CORS middleware
+	if s.cors != nil {
+		s.cors.WriteHeaders(w)
+	}
+	if apiErr.RetryAfter > 0 {
+		w.Response.Header.Set("Retry-After", strconv.Itoa(apiErr.RetryAfter))
+	}
 
-func writeErrResponse(w *fasthttp.RequestCtx, err error) {
-	if _, ok := err.(*ecode.APIError); !ok {
-		err = ecode.Errorf(500, err.Error())
+	var body interface{} = apiErr
+	contentType := encoder.ContentType()
+	if encoder.ContentType() == coder.JSON.ContentType() && (s.problemJSON || mediaType == ecode.ProblemContentType) {
+		body = apiErr.ToProblem(string(w.Path()))
+		contentType = ecode.ProblemContentType
 	}
-	w.Response.SetStatusCode(ecode.ToHttpCode(err))
-	bs, _ := encoder(err)
+	w.Response.Header.SetContentType(contentType)
+	bs, _ := encoder.Marshal(body)
 	w.Write(bs)
 }
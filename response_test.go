@@ -0,0 +1,79 @@
+package goapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ottstack/goapi/pkg/ecode"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestWriteErrResponse(t *testing.T) {
+	s := &Server{}
+	apiErr := (&ecode.APIError{Code: 429, Message: "slow down"}).WithRetryAfter(2 * time.Second).WithDebug("bucket empty")
+
+	fastReq := &fasthttp.RequestCtx{}
+	s.writeErrResponse(fastReq, apiErr)
+	assert.Equal(t, 429, fastReq.Response.StatusCode())
+	assert.Equal(t, "2", string(fastReq.Response.Header.Peek("Retry-After")))
+	assert.NotContains(t, string(fastReq.Response.Body()), "debug")
+	assert.NotNil(t, apiErr.Debug)
+
+	fastReq = &fasthttp.RequestCtx{}
+	fastReq.Request.SetRequestURI("/api/users/1")
+	fastReq.Request.Header.Set("Accept", ecode.ProblemContentType)
+	s.writeErrResponse(fastReq, (&ecode.APIError{Code: 400, Message: "invalid user"}).WithViolation("name", "is required"))
+	assert.Equal(t, ecode.ProblemContentType, string(fastReq.Response.Header.ContentType()))
+	problem := &ecode.Problem{}
+	assert.Nil(t, json.Unmarshal(fastReq.Response.Body(), problem))
+	assert.Equal(t, "Bad Request", problem.Title)
+	assert.Equal(t, 400, problem.Status)
+	assert.Equal(t, "/api/users/1", problem.Instance)
+	assert.Equal(t, "name", problem.Violations[0].Field)
+
+	s.debug = true
+	fastReq = &fasthttp.RequestCtx{}
+	s.writeErrResponse(fastReq, apiErr)
+	assert.Contains(t, string(fastReq.Response.Body()), "bucket empty")
+
+	fastReq = &fasthttp.RequestCtx{}
+	s.writeErrResponse(fastReq, errors.New("dial db: connection refused"))
+	assert.Equal(t, 500, fastReq.Response.StatusCode())
+	assert.Equal(t, "application/json", string(fastReq.Response.Header.ContentType()))
+	assert.Contains(t, string(fastReq.Response.Body()), "connection refused")
+
+	s.debug = false
+	fastReq = &fasthttp.RequestCtx{}
+	s.writeErrResponse(fastReq, errors.New("dial db: connection refused"))
+	assert.NotContains(t, string(fastReq.Response.Body()), "connection refused")
+	assert.Contains(t, string(fastReq.Response.Body()), "Internal server error")
+
+	fastReq = &fasthttp.RequestCtx{}
+	s.writeErrResponse(fastReq, fmt.Errorf("query user 1: %w", ecode.Wrap(errors.New("sql: no rows"), 404, "User not found")))
+	assert.Equal(t, 404, fastReq.Response.StatusCode())
+	assert.Equal(t, `{"code":404,"message":"User not found"}`, string(fastReq.Response.Body()))
+}
+
+func TestProblemAccept(t *testing.T) {
+	s := &Server{}
+	for accept, contentType := range map[string]string{
+		"application/problem+json":                                  ecode.ProblemContentType,
+		"application/json;q=0.5, application/problem+json":          ecode.ProblemContentType,
+		"application/problem+json;q=0, application/json":            "application/json",
+		"application/problem+json;q=0":                              "application/json",
+		"application/json, application/problem+json;q=0.5":          "application/json",
+		"application/problem+json;q=0.9, application/msgpack;q=0.5": ecode.ProblemContentType,
+		"application/problem+json;q=0.5, application/msgpack;q=0.9": "application/msgpack",
+		"text/html, application/problem+json;q=0.1, */*;q=0.05":     ecode.ProblemContentType,
+		"*/*": "application/json",
+	} {
+		fastReq := &fasthttp.RequestCtx{}
+		fastReq.Request.Header.Set("Accept", accept)
+		s.writeErrResponse(fastReq, &ecode.APIError{Code: 400, Message: "invalid user"})
+		assert.Equal(t, contentType, string(fastReq.Response.Header.ContentType()), accept)
+	}
+}
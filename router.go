@@ -0,0 +1,254 @@
+package goapi
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/ottstack/goapi/pkg/middleware"
+	"github.com/valyala/fasthttp"
+)
+
+// ServiceRoutes can be implemented by a service to expose its methods on
+// custom HTTP routes instead of the default "POST {HomePath}{Service}/{Method}".
+//
+// Keys are method names, values are routes like "GET users/{id}". The path
+// is relative to HomePath and each {param} is bound into the request struct
+// field with the matching `path` tag.
+type ServiceRoutes interface {
+	Routes() map[string]string
+}
+
+// anyVerb is the verb of handlers registered without method
+const anyVerb = ""
+
+var supportedVerbs = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// handler is either a typed method or a raw fasthttp handler
+type handler struct {
+	info  *methodInfo
+	raw   func(*fasthttp.RequestCtx)
+	group *Group
+	call  *middleware.CallInfo
+}
+
+// router is a tree of path segments supporting {param} and trailing *wildcard
+// segments. Static segments take priority over parameters, parameters over wildcards.
+type router struct {
+	root *node
+}
+
+type node struct {
+	static   map[string]*node
+	param    *node
+	wildcard *node
+	name     string // parameter name of param or wildcard node
+
+	pattern  string
+	params   []string // parameter names along the pattern
+	handlers map[string]*handler
+}
+
+func newCallInfo(verb, pattern string, info *methodInfo) *middleware.CallInfo {
+	call := &middleware.CallInfo{Route: pattern, Verb: verb}
+	if info != nil {
+		call.ServiceName = info.serviceName
+		call.MethodName = info.methodName
+		call.IsStream = info.isWebsocket
+		call.ReqType = info.reqType
+		call.RspType = info.rspType
+		call.OperationID = info.operationID()
+		call.Security = info.options.Security
+	}
+	return call
+}
+
+func newRouter() *router {
+	return &router{root: &node{}}
+}
+
+// parseRoute splits "VERB path" into its verb and path
+func parseRoute(r string) (string, string, error) {
+	parts := strings.Fields(r)
+	if len(parts) != 2 {
+		return "", "", errors.Errorf("route %q should be in the form of \"VERB path\"", r)
+	}
+	verb := strings.ToUpper(parts[0])
+	if !supportedVerbs[verb] {
+		return "", "", errors.Errorf("unsupported http method %s in route %q", parts[0], r)
+	}
+	return verb, parts[1], nil
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+// add registers the handler and returns the parameter names in the pattern
+func (r *router) add(verb, pattern string, h *handler) ([]string, error) {
+	n := r.root
+	var params []string
+	segments := splitPath(pattern)
+	for i, seg := range segments {
+		switch {
+		case strings.HasPrefix(seg, "*"):
+			name := seg[1:]
+			if name == "" || i != len(segments)-1 {
+				return nil, errors.Errorf("wildcard %q should be named and at the end of %s", seg, pattern)
+			}
+			if n.wildcard == nil {
+				n.wildcard = &node{name: name}
+			} else if n.wildcard.name != name {
+				return nil, errors.Errorf("wildcard %s in %s conflicts with *%s in %s", seg, pattern, n.wildcard.name, n.wildcard.pattern)
+			}
+			n = n.wildcard
+			params = append(params, name)
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			name := seg[1 : len(seg)-1]
+			if name == "" || strings.ContainsAny(name, "{}") {
+				return nil, errors.Errorf("invalid path parameter %q in %s", seg, pattern)
+			}
+			if n.param == nil {
+				n.param = &node{name: name}
+			} else if n.param.name != name {
+				return nil, errors.Errorf("path parameter %s in %s conflicts with {%s}", seg, pattern, n.param.name)
+			}
+			n = n.param
+			params = append(params, name)
+		case strings.ContainsAny(seg, "{}*"):
+			return nil, errors.Errorf("invalid path segment %q in %s", seg, pattern)
+		default:
+			if n.static == nil {
+				n.static = map[string]*node{}
+			}
+			child, ok := n.static[seg]
+			if !ok {
+				child = &node{}
+				n.static[seg] = child
+			}
+			n = child
+		}
+	}
+	if n.handlers == nil {
+		n.handlers = map[string]*handler{}
+	}
+	if _, ok := n.handlers[verb]; ok {
+		return nil, errors.Errorf("%s already registered", strings.TrimSpace(verb+" "+pattern))
+	}
+	n.pattern = pattern
+	n.params = params
+	h.call = newCallInfo(verb, pattern, h.info)
+	n.handlers[verb] = h
+	return params, nil
+}
+
+func (n *node) find(segments []string, values []string) (*node, []string) {
+	if len(segments) == 0 {
+		if n.handlers != nil {
+			return n, values
+		}
+		return nil, nil
+	}
+	seg := segments[0]
+	if child, ok := n.static[seg]; ok {
+		if found, vals := child.find(segments[1:], values); found != nil {
+			return found, vals
+		}
+	}
+	if n.param != nil && seg != "" {
+		if found, vals := n.param.find(segments[1:], append(values, seg)); found != nil {
+			return found, vals
+		}
+	}
+	if n.wildcard != nil && n.wildcard.handlers != nil {
+		return n.wildcard, append(values, strings.Join(segments, "/"))
+	}
+	return nil, nil
+}
+
+// lookup finds the handler of request served on listener and stores path parameters
+// as user values. If the handler is not found, the allowed methods of path or the
+// redirect location for trailing slash is returned instead. Handlers not exposed on
+// listener are treated as absent.
+func (r *router) lookup(fastReq *fasthttp.RequestCtx, listener, verb, path string) (h *handler, allow []string, redirect string) {
+	n, values := r.root.find(splitPath(path), nil)
+	if n == nil || !n.exposedOn(listener) {
+		if path == "/" {
+			return nil, nil, ""
+		}
+		other := path + "/"
+		if strings.HasSuffix(path, "/") {
+			other = strings.TrimSuffix(path, "/")
+		}
+		if found, _ := r.root.find(splitPath(other), nil); found != nil && found.exposedOn(listener) {
+			return nil, nil, other
+		}
+		return nil, nil, ""
+	}
+	h = n.handler(listener, verb)
+	if h == nil && verb == http.MethodHead {
+		h = n.handler(listener, http.MethodGet)
+	}
+	if h == nil {
+		h = n.handler(listener, anyVerb)
+	}
+	if h == nil {
+		verbs := map[string]bool{http.MethodOptions: true}
+		for v := range n.handlers {
+			if n.handler(listener, v) == nil {
+				continue
+			}
+			verbs[v] = true
+			if v == http.MethodGet {
+				verbs[http.MethodHead] = true
+			}
+		}
+		for v := range verbs {
+			allow = append(allow, v)
+		}
+		sort.Strings(allow)
+		return nil, allow, ""
+	}
+	for i, name := range n.params {
+		fastReq.SetUserValue(name, values[i])
+	}
+	return h, nil, ""
+}
+
+// handler returns the handler of verb if it is exposed on listener
+func (n *node) handler(listener, verb string) *handler {
+	if h, ok := n.handlers[verb]; ok && h.group.exposedOn(listener) {
+		return h
+	}
+	return nil
+}
+
+// exposedOn reports whether any handler of n is exposed on listener
+func (n *node) exposedOn(listener string) bool {
+	for _, h := range n.handlers {
+		if h.group.exposedOn(listener) {
+			return true
+		}
+	}
+	return false
+}
+
+// docPath converts route pattern to OpenAPI path template
+func docPath(pattern string) string {
+	segments := splitPath(pattern)
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, "*") {
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
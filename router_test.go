@@ -0,0 +1,110 @@
+package goapi
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ottstack/goapi/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRouter(t *testing.T) {
+	r := newRouter()
+	files := &handler{}
+	file := &handler{}
+	static := &handler{}
+	any := &handler{}
+
+	params, err := r.add("GET", "/files/{name}", file)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"name"}, params)
+	_, err = r.add("GET", "/files/", files)
+	assert.Nil(t, err)
+	_, err = r.add("DELETE", "/files/{name}", file)
+	assert.Nil(t, err)
+	_, err = r.add("GET", "/static/*filepath", static)
+	assert.Nil(t, err)
+	_, err = r.add(anyVerb, "/files/latest", any)
+	assert.Nil(t, err)
+
+	_, err = r.add("GET", "/files/{name}", file)
+	assert.NotNil(t, err)
+	_, err = r.add("GET", "/files/{id}/info", file)
+	assert.NotNil(t, err)
+	_, err = r.add("GET", "/static/*path/x", file)
+	assert.NotNil(t, err)
+
+	fastReq := &fasthttp.RequestCtx{}
+	h, _, _ := r.lookup(fastReq, DefaultListener, "GET", "/files/a.txt")
+	assert.Equal(t, file, h)
+	assert.Equal(t, "a.txt", fastReq.UserValue("name"))
+
+	h, _, _ = r.lookup(fastReq, DefaultListener, "HEAD", "/files/a.txt")
+	assert.Equal(t, file, h)
+
+	h, _, _ = r.lookup(fastReq, DefaultListener, "POST", "/files/latest")
+	assert.Equal(t, any, h)
+
+	h, _, _ = r.lookup(fastReq, DefaultListener, "GET", "/files/")
+	assert.Equal(t, files, h)
+
+	h, _, _ = r.lookup(fastReq, DefaultListener, "GET", "/static/css/app.css")
+	assert.Equal(t, static, h)
+	assert.Equal(t, "css/app.css", fastReq.UserValue("filepath"))
+
+	h, allow, _ := r.lookup(fastReq, DefaultListener, "PUT", "/files/a.txt")
+	assert.Nil(t, h)
+	assert.Equal(t, []string{"DELETE", "GET", "HEAD", "OPTIONS"}, allow)
+
+	h, allow, redirect := r.lookup(fastReq, DefaultListener, "GET", "/files")
+	assert.Nil(t, h)
+	assert.Nil(t, allow)
+	assert.Equal(t, "/files/", redirect)
+
+	h, _, redirect = r.lookup(fastReq, DefaultListener, "GET", "/files/a.txt/")
+	assert.Nil(t, h)
+	assert.Equal(t, "/files/a.txt", redirect)
+
+	h, allow, redirect = r.lookup(fastReq, DefaultListener, "GET", "/unknown")
+	assert.Nil(t, h)
+	assert.Nil(t, allow)
+	assert.Equal(t, "", redirect)
+
+	assert.Equal(t, "/static/{filepath}", docPath("/static/*filepath"))
+}
+
+func TestCallInfo(t *testing.T) {
+	s := NewServer()
+	var got *middleware.CallInfo
+	s.Use(func(ctx context.Context, fastReq *fasthttp.RequestCtx, method middleware.MethodFunc, req, rsp interface{}) error {
+		got = middleware.GetCallInfo(ctx)
+		return method(ctx, req, rsp)
+	})
+	s.RegisterService(&OptionsService{}, &EchoService{})
+	s.Group("/raw").RegisterHTTP("/files/{name}", func(fastReq *fasthttp.RequestCtx) {})
+
+	doRequest(s, "POST", "/api/OptionsService/Hello", `{"name":"a"}`)
+	assert.Equal(t, &middleware.CallInfo{
+		ServiceName: "OptionsService",
+		MethodName:  "Hello",
+		Route:       "/api/OptionsService/Hello",
+		Verb:        "POST",
+		ReqType:     reflect.TypeOf(optionsReq{}),
+		RspType:     reflect.TypeOf(optionsRsp{}),
+		OperationID: "OptionsServiceHello",
+		Security:    []string{"bearer"},
+	}, got)
+
+	got = nil
+	doRequest(s, "DELETE", "/raw/files/a.txt", "")
+	assert.Equal(t, &middleware.CallInfo{Route: "/raw/files/{name}"}, got)
+
+	h, _, _ := s.router.lookup(&fasthttp.RequestCtx{}, DefaultListener, "GET", "/api/EchoService/StreamEcho")
+	if assert.NotNil(t, h) {
+		assert.True(t, h.call.IsStream)
+		assert.Equal(t, "GET", h.call.Verb)
+		assert.Equal(t, "StreamEcho", h.call.MethodName)
+	}
+}
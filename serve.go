@@ -5,14 +5,20 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/url"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"reflect"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/fasthttp/websocket"
 	"github.com/go-errors/errors"
 	"github.com/kelseyhightower/envconfig"
+	"github.com/ottstack/goapi/pkg/coder"
 	"github.com/ottstack/goapi/pkg/ecode"
 	"github.com/ottstack/goapi/pkg/middleware"
 	"github.com/valyala/fasthttp"
@@ -20,25 +26,75 @@ import (
 )
 
 type Server struct {
-	methods       map[string]methodFactory
-	streamMethods map[string]bool
-	api           *openapi
-	middlewares   []middleware.Middleware
-	ctx           context.Context
-	cancelFunc    context.CancelFunc
-	addr          string
-	swaggerPath   string
-	apiContent    []byte
-
-	rawHandler map[string]func(*fasthttp.RequestCtx)
-
-	crossDomain bool
+	router      *router
+	root        *Group
+	api         *openapi
+	docs        *docsUI
+	middlewares []middleware.Middleware
+	ctx         context.Context
+	cancelFunc  context.CancelFunc
+	listeners   []*listener
+	swaggerPath string
+	apiContent  []byte
+	cors        *middleware.CORSPolicy
+	problemJSON bool
+	debug       bool
+	upgrader    websocket.FastHTTPUpgrader
+
+	certs             *certReloader
+	docsListeners     []string
+	exposedGroups     []*Group
+	strictContentType bool
+	maxBodySize       int // limit of request body of methods without MaxBodySize
+	maxMethodBodySize int // largest MaxBodySize of methods
+	shutdownTimeout   time.Duration
+	shutdownHooks     []func(context.Context) error
+	serveMu           sync.Mutex // listeners are started or shut down exclusively
+	shutdownOnce      sync.Once
+	shutdownErr       error
+	streams           streamSet
 }
 
 type serveConfig struct {
-	Addr        string
-	HomePath    string
-	CrossDomain bool
+	Addr              string
+	HomePath          string
+	CrossDomain       bool          // allow any origin with credentials, use Server.UseCORS for more options
+	ShutdownTimeout   time.Duration `envconfig:"SHUTDOWN_TIMEOUT"`
+	TLSCert           string        `envconfig:"TLS_CERT"`
+	TLSKey            string        `envconfig:"TLS_KEY"`
+	ClientCA          string        `envconfig:"CLIENT_CA"`
+	ClientAuth        string        `envconfig:"CLIENT_AUTH"`  // require or optional, only works with ClientCA
+	ProblemJSON       bool          `envconfig:"PROBLEM_JSON"` // render JSON errors as application/problem+json
+	Debug             bool          // respond debug information of errors
+	StrictContentType bool          `envconfig:"STRICT_CONTENT_TYPE"` // reject request body of unknown Content-Type with 415
+	MaxBodySize       int           `envconfig:"MAX_BODY_SIZE"`       // limit of request body in bytes, MaxBodySize of method overrides it
+	DocsCDN           bool          `envconfig:"DOCS_CDN"`            // load documentation UI from CDN instead of embedded assets
+	APITitle          string        `envconfig:"API_TITLE"`
+	APIVersion        string        `envconfig:"API_VERSION"`
+	APIDescription    string        `envconfig:"API_DESCRIPTION"`
+	APIServers        []string      `envconfig:"API_SERVERS"`    // comma separated base URLs of API
+	APITags           []string      `envconfig:"API_TAGS"`       // comma separated name:description of tags
+	APIExtensions     []string      `envconfig:"API_EXTENSIONS"` // comma separated x-key:value of string extensions
+}
+
+// apiInfo returns the metadata of OpenAPI document from environment
+func (c *serveConfig) apiInfo() APIInfo {
+	info := APIInfo{Title: c.APITitle, Version: c.APIVersion, Description: c.APIDescription}
+	for _, url := range c.APIServers {
+		info.Servers = append(info.Servers, APIServer{URL: strings.TrimSpace(url)})
+	}
+	for _, tag := range c.APITags {
+		name, description, _ := strings.Cut(tag, ":")
+		info.Tags = append(info.Tags, APITag{Name: strings.TrimSpace(name), Description: strings.TrimSpace(description)})
+	}
+	if len(c.APIExtensions) > 0 {
+		info.Extensions = map[string]interface{}{}
+	}
+	for _, ext := range c.APIExtensions {
+		k, v, _ := strings.Cut(ext, ":")
+		info.Extensions[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return info
 }
 
 type methodFactory func() (middleware.MethodFunc, interface{}, interface{})
@@ -48,21 +104,28 @@ type methodInfo struct {
 	methodName  string
 	serviceName string
 
-	tags    []string
-	summary string
+	tags        []string
+	summary     string
+	description string
 
 	factory     methodFactory
 	reqType     reflect.Type
 	rspType     reflect.Type
+	verb        string
 	path        string
+	params      []boundField
+	codecs      *coder.Set
 	isWebsocket bool
+	options     MethodOptions
 }
 
 func NewServer() *Server {
 	cfg := &serveConfig{
-		Addr:        "127.0.0.1:8081",
-		HomePath:    "/api/",
-		CrossDomain: false,
+		Addr:            "127.0.0.1:8081",
+		HomePath:        "/api/",
+		CrossDomain:     false,
+		ShutdownTimeout: 30 * time.Second,
+		MaxBodySize:     fasthttp.DefaultMaxRequestBodySize,
 	}
 	err := envconfig.Process("SERVE", cfg)
 	if err != nil {
@@ -71,33 +134,48 @@ func NewServer() *Server {
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	sv := &Server{
-		swaggerPath:   cfg.HomePath,
-		addr:          cfg.Addr,
-		ctx:           ctx,
-		crossDomain:   cfg.CrossDomain,
-		cancelFunc:    cancelFunc,
-		methods:       make(map[string]methodFactory),
-		streamMethods: make(map[string]bool),
-		rawHandler:    make(map[string]func(*fasthttp.RequestCtx)),
-	}
-	sv.api = newOpenapi(cfg.HomePath)
-	sv.api.parseType("", reflect.TypeOf(&ecode.APIError{}))
+		swaggerPath: cfg.HomePath,
+		listeners:   []*listener{{name: DefaultListener, addr: cfg.Addr}},
+		ctx:         ctx,
+		cancelFunc:  cancelFunc,
+		router:      newRouter(),
+		problemJSON: cfg.ProblemJSON,
+		debug:       cfg.Debug,
+
+		strictContentType: cfg.StrictContentType,
+		maxBodySize:       cfg.MaxBodySize,
+
+		shutdownTimeout: cfg.ShutdownTimeout,
+	}
+	if cfg.MaxBodySize <= 0 {
+		sv.checkError(errors.Errorf("SERVE_MAX_BODY_SIZE should be positive"))
+	}
+	if cfg.TLSCert != "" || cfg.TLSKey != "" || cfg.ClientCA != "" {
+		sv.certs, err = newCertReloader(cfg.TLSCert, cfg.TLSKey, cfg.ClientCA, cfg.ClientAuth)
+		sv.checkError(err)
+	}
+	sv.upgrader = upgrader
+	sv.upgrader.CheckOrigin = sv.checkOrigin
+	if cfg.CrossDomain {
+		sv.UseCORS(middleware.CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true})
+	}
+	sv.root = &Group{server: sv}
+	sv.api = newOpenapi()
+	sv.checkError(sv.api.setInfo(cfg.apiInfo()))
+	sv.docs = newDocsUI()
+	sv.addBuiltinDocs(cfg.DocsCDN)
+	for _, t := range []reflect.Type{apiErrorType, problemType} {
+		_, err := sv.api.parseType("", t)
+		sv.checkError(err)
+	}
 	return sv
 }
 
+// RegisterHTTP registers raw fasthttp handler on path. The path can be
+// prefixed with a method like "GET /static/*filepath" to serve that method
+// only, path parameters are stored in the user values of request.
 func (s *Server) RegisterHTTP(path string, function func(*fasthttp.RequestCtx)) {
-	if !strings.HasPrefix(path, "/") {
-		path = "/" + path
-	}
-	if _, ok := s.methods[path]; ok {
-		err := errors.Errorf("%s already registered for function", path)
-		s.checkError(err)
-	}
-	if _, ok := s.rawHandler[path]; ok {
-		err := errors.Errorf("%s already registered for http handler", path)
-		s.checkError(err)
-	}
-	s.rawHandler[path] = function
+	s.root.RegisterHTTP(path, function)
 }
 
 func (s *Server) Use(m middleware.Middleware) *Server {
@@ -106,9 +184,12 @@ func (s *Server) Use(m middleware.Middleware) *Server {
 }
 
 func (s *Server) RegisterService(services ...interface{}) {
-	if err := s.parse(services); err != nil {
-		s.checkError(err)
-	}
+	s.root.RegisterService(services...)
+}
+
+// RegisterServiceWith registers service with middlewares only for its methods
+func (s *Server) RegisterServiceWith(service interface{}, middlewares ...middleware.Middleware) {
+	s.root.RegisterServiceWith(service, middlewares...)
 }
 
 func (s *Server) checkError(err error) {
@@ -128,24 +209,106 @@ func (s *Server) checkError(err error) {
 	}
 }
 
+// Serve serves until SIGINT or SIGTERM is received, then shuts down gracefully
 func (s *Server) Serve() error {
-	defer s.cancelFunc()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return s.ServeContext(ctx)
+}
+
+// ServeContext serves until ctx is done, then shuts down gracefully within
+// SERVE_SHUTDOWN_TIMEOUT. The error of serving or shutdown is returned.
+func (s *Server) ServeContext(ctx context.Context) error {
 	// maxprocs
 	maxprocs.Set(maxprocs.Logger(func(s string, args ...interface{}) {
 		log.Printf(s, args...)
 	}))
 
-	showAddr := s.addr
-	addrInfo := strings.SplitN(s.addr, ":", 2)
-	if addrInfo[0] == "" || addrInfo[0] == "0" || addrInfo[0] == "0.0.0.0" {
-		showAddr = "localhost:" + addrInfo[1]
-	}
-	log.Println("Serving API on http://" + showAddr + s.swaggerPath)
 	s.apiContent = s.api.getOpenAPIV3()
-	return fasthttp.ListenAndServe(s.addr, s.serve)
+	serveErr, err := s.startListeners()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+	case <-s.ctx.Done():
+	}
+	// stopped by Shutdown, its result is returned once it's done
+	if s.ctx.Err() != nil {
+		return errors.Join(err, s.Shutdown(context.Background()))
+	}
+	log.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	return errors.Join(err, s.Shutdown(shutdownCtx))
+}
+
+// startListeners serves on every listener, the results of serving are sent to the returned channel
+func (s *Server) startListeners() (chan error, error) {
+	s.serveMu.Lock()
+	defer s.serveMu.Unlock()
+	if s.ctx.Err() != nil {
+		return nil, errors.New("server is shut down")
+	}
+	if err := s.checkExposure(); err != nil {
+		return nil, err
+	}
+	if err := s.checkSecurity(); err != nil {
+		return nil, err
+	}
+	for _, l := range s.listeners {
+		if err := l.listen(s.certs); err != nil {
+			s.closeListeners()
+			s.cancelFunc()
+			return nil, err
+		}
+	}
+
+	serveErr := make(chan error, len(s.listeners))
+	for _, l := range s.listeners {
+		l := l
+		l.server = &fasthttp.Server{
+			Handler: func(fastReq *fasthttp.RequestCtx) {
+				s.serve(l.name, fastReq)
+			},
+			// bodies are read by fasthttp within the largest limit, then checked by limit of method
+			MaxRequestBodySize: s.requestBodyLimit(),
+		}
+		log.Printf("Serving API on %s (%s)", l.url(s.swaggerPath), l.name)
+		go func() {
+			serveErr <- l.server.Serve(l.ln)
+		}()
+	}
+	return serveErr, nil
+}
+
+// requestBodyLimit returns the largest body size of server and methods read by fasthttp
+func (s *Server) requestBodyLimit() int {
+	if s.maxMethodBodySize > s.maxBodySize {
+		return s.maxMethodBodySize
+	}
+	return s.maxBodySize
 }
 
-func (s *Server) parse(services []interface{}) error {
+// ServeListener serves on ln instead of SERVE_ADDR until SIGINT or SIGTERM is
+// received, TCP listener is wrapped with TLS if SERVE_TLS_CERT is set
+func (s *Server) ServeListener(ln net.Listener) error {
+	s.listeners[0].ln = ln
+	s.listeners[0].addr = ln.Addr().String()
+	return s.Serve()
+}
+
+func (s *Server) closeListeners() {
+	for _, l := range s.listeners {
+		if l.ln != nil {
+			l.ln.Close()
+		}
+	}
+}
+
+func (s *Server) parse(g *Group, services []interface{}) error {
 	for _, sv := range services {
 		svType := reflect.TypeOf(sv)
 		svValue := reflect.ValueOf(sv)
@@ -153,143 +316,268 @@ func (s *Server) parse(services []interface{}) error {
 			return errors.Errorf("service paramter %s should be pointer to struct", svType)
 		}
 		svName := svType.Elem().Name()
+		s.api.addServiceTag(svName, sv)
+		routes := map[string]string{}
+		if sr, ok := sv.(ServiceRoutes); ok {
+			for k, v := range sr.Routes() {
+				routes[k] = v
+			}
+		}
+		options := map[string]MethodOptions{}
+		if so, ok := sv.(ServiceMethodOptions); ok {
+			for k, v := range so.MethodOptions() {
+				options[k] = v
+			}
+		}
 		for i := 0; i < svType.NumMethod(); i++ {
 			m := svType.Method(i)
-			path := s.swaggerPath + svName + "/" + m.Name
+			if isServiceHook(sv, m.Name) {
+				continue
+			}
 			info := &methodInfo{
-				path:        path,
+				path:        g.prefix + s.swaggerPath + svName + "/" + m.Name,
 				tags:        []string{svName},
 				methodType:  m.Type,
 				methodValue: svValue.MethodByName(m.Name),
 				methodName:  m.Name,
 				serviceName: svName,
 			}
+			info.summary, info.description = splitDoc(lookupDoc(svType, m.Name))
 			if err := parseMethods(info); err != nil {
 				return err
 			}
-
+			info.verb = http.MethodPost
 			if info.isWebsocket {
-				s.streamMethods[path] = true
+				info.verb = http.MethodGet
 			}
-			s.methods[path] = info.factory
+			if r, ok := routes[m.Name]; ok {
+				verb, path, err := parseRoute(r)
+				if err != nil {
+					return err
+				}
+				if info.isWebsocket && verb != http.MethodGet {
+					return errors.Errorf("stream method %s.%s should be routed with GET instead of %s", svName, m.Name, verb)
+				}
+				info.verb = verb
+				info.path = g.prefix + s.swaggerPath + strings.TrimPrefix(path, "/")
+				delete(routes, m.Name)
+			}
+			if opts, ok := options[m.Name]; ok {
+				for k := range opts.Extensions {
+					if !strings.HasPrefix(k, "x-") {
+						return errors.Errorf("extension %s of %s.%s should start with x-", k, svName, m.Name)
+					}
+				}
+				info.options = opts
+				delete(options, m.Name)
+			}
+			if err := s.addRoute(g, info); err != nil {
+				return err
+			}
+			if err := s.api.addMethod(info); err != nil {
+				return err
+			}
+		}
+		for name := range routes {
+			return errors.Errorf("route for %s.%s is defined but the method is not found", svName, name)
+		}
+		for name := range options {
+			return errors.Errorf("options for %s.%s are defined but the method is not found", svName, name)
+		}
+	}
+	return nil
+}
 
-			s.api.addMethod(info)
+func (s *Server) addRoute(g *Group, info *methodInfo) error {
+	params, err := s.router.add(info.verb, info.path, &handler{info: info, group: g})
+	if err != nil {
+		return err
+	}
+	fields := map[string]bool{}
+	for _, f := range info.params {
+		if f.in == pathTag {
+			fields[f.name] = true
+		}
+	}
+	if info.options.MaxBodySize > s.maxMethodBodySize {
+		s.maxMethodBodySize = info.options.MaxBodySize
+	}
+	routeParams := map[string]bool{}
+	for _, p := range params {
+		if !fields[p] {
+			return errors.Errorf("path parameter %s in %s has no field with tag `path:\"%s\"` in %s", p, info.path, p, info.reqType)
+		}
+		routeParams[p] = true
+	}
+	// the field would never be bound and is documented with a parameter not in path
+	for _, f := range info.params {
+		if f.in == pathTag && !routeParams[f.name] {
+			return errors.Errorf("field %s with tag `path:\"%s\"` in %s has no path parameter in %s", f.field.Name, f.name, info.reqType, info.path)
 		}
 	}
 	return nil
 }
 
-// serve as http handler
-func (s *Server) serve(fastReq *fasthttp.RequestCtx) {
-	// serve openapi
-	path := string(fastReq.Path())
+// chain wraps method with global middlewares, the ones of handler group and method
+func (s *Server) chain(fastReq *fasthttp.RequestCtx, h *handler, method middleware.MethodFunc) middleware.MethodFunc {
+	if h.info != nil {
+		method = chainMiddlewares(fastReq, h.info.options.Middlewares, method)
+		method = h.info.options.withTimeout(method)
+	}
+	method = h.group.chain(fastReq, method)
+	return chainMiddlewares(fastReq, s.middlewares, method)
+}
+
+// preflight answers OPTIONS request of path without OPTIONS handler. Middlewares
+// of the route requested by Access-Control-Request-Method run, so CORS of groups
+// and methods answers preflight request, otherwise only the global ones run.
+func (s *Server) preflight(ctx context.Context, listener, path string, fastReq *fasthttp.RequestCtx) {
+	noop := func(context.Context, interface{}, interface{}) error { return nil }
+	method := chainMiddlewares(fastReq, s.middlewares, noop)
+	requested := strings.ToUpper(string(fastReq.Request.Header.Peek("Access-Control-Request-Method")))
+	if requested != "" && requested != fasthttp.MethodOptions {
+		if h, _, _ := s.router.lookup(fastReq, listener, requested, path); h != nil {
+			middleware.SetCallInfo(fastReq, h.call)
+			method = s.chain(fastReq, h, noop)
+		}
+	}
+	if err := method(ctx, nil, nil); err != nil {
+		s.writeErrResponse(fastReq, err)
+		return
+	}
+	fastReq.SetStatusCode(fasthttp.StatusNoContent)
+}
+
+// serveDocs serves OpenAPI document, UI pages and their assets, returns false if path is not for document
+func (s *Server) serveDocs(path string, fastReq *fasthttp.RequestCtx) bool {
 	if path == s.swaggerPath+"api.json" {
+		fastReq.Response.Header.SetContentType("application/json")
 		fastReq.Write(s.apiContent)
-		return
+		return true
 	}
-	if path == s.swaggerPath {
-		fastReq.Response.Header.Set("Content-Type", "text/html; charset=utf-8")
-		fastReq.Write(s.api.getSwaggerHTML())
-		return
+	if name := strings.TrimPrefix(path, s.swaggerPath+"assets/"); name != path {
+		return s.docs.serveAsset(name, fastReq)
 	}
-	if path == s.swaggerPath+"doc" {
+	if html, ok := s.docs.pages[path]; ok {
 		fastReq.Response.Header.Set("Content-Type", "text/html; charset=utf-8")
-		fastReq.Write(s.api.getDocHTML())
-		return
+		fastReq.Write(html)
+		return true
 	}
+	return false
+}
 
-	method := strings.ToUpper(string(fastReq.Method()))
-	if s.crossDomain {
-		referer := string(fastReq.Referer())
-		if u, _ := url.Parse(referer); u != nil {
-			fastReq.Response.Header.Set("Access-Control-Allow-Origin", fmt.Sprintf("%s://%s", u.Scheme, u.Host))
-		} else {
-			fastReq.Response.Header.Set("Access-Control-Allow-Origin", "*")
-		}
-		fastReq.Response.Header.Set("Access-Control-Allow-Credentials", "true")
-		fastReq.Response.Header.Set("Access-Control-Allow-Headers", "authorization, origin, content-type, accept")
-		fastReq.Response.Header.Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS,DELETE,PUT")
-		if method == "OPTIONS" {
+// serve as http handler of the named listener
+func (s *Server) serve(listener string, fastReq *fasthttp.RequestCtx) {
+	// serve openapi
+	path := string(fastReq.Path())
+	if s.docsListeners == nil || containsString(s.docsListeners, listener) {
+		if s.serveDocs(path, fastReq) {
 			return
 		}
 	}
 
+	method := strings.ToUpper(string(fastReq.Method()))
 	var ctx context.Context = fastReq
-	hd, ok := s.rawHandler[path]
-	if ok {
-		realMethod := func(ctx context.Context, req, rsp interface{}) error {
-			hd(fastReq)
-			return nil
+	fastReq.SetUserValue(requestCtxKey{}, fastReq)
+	h, allow, redirect := s.router.lookup(fastReq, listener, method, path)
+	if redirect != "" {
+		code := fasthttp.StatusMovedPermanently
+		if method != fasthttp.MethodGet && method != fasthttp.MethodHead {
+			code = fasthttp.StatusPermanentRedirect
 		}
-		// Middleware
-		for i := range s.middlewares {
-			mware := s.middlewares[len(s.middlewares)-i-1]
-			realMethod = func(mm middleware.MethodFunc) middleware.MethodFunc {
-				return func(ctx context.Context, req, rsp interface{}) error {
-					return mware(ctx, fastReq, mm, req, rsp)
-				}
-			}(realMethod)
+		if args := fastReq.QueryArgs().QueryString(); len(args) > 0 {
+			redirect += "?" + string(args)
 		}
-		if err := realMethod(ctx, nil, nil); err != nil {
-			writeErrResponse(fastReq, err)
+		fastReq.Redirect(redirect, code)
+		return
+	}
+	if len(allow) > 0 {
+		fastReq.Response.Header.Set("Allow", strings.Join(allow, ", "))
+		if method == fasthttp.MethodOptions {
+			s.preflight(ctx, listener, path, fastReq)
 			return
 		}
+		s.writeErrResponse(fastReq, &ecode.APIError{Code: 405, Message: fmt.Sprintf("Method %s not allowed for %s", method, path)})
 		return
 	}
+	if h == nil {
+		s.writeErrResponse(fastReq, &ecode.APIError{Code: 404, Message: fmt.Sprintf("Request %s %s not found", method, path)})
+		return
+	}
+	middleware.SetCallInfo(fastReq, h.call)
+	fastReq.SetUserValue(requestInfoKey{}, &RequestInfo{Method: method, Path: path, Listener: listener, CallInfo: h.call, fastReq: fastReq})
 
-	// path to func
-	factory, ok := s.methods[path]
-	if !ok {
-		writeErrResponse(fastReq, &ecode.APIError{Code: 404, Message: fmt.Sprintf("Request %s %s not found", method, path)})
+	if h.raw != nil {
+		realMethod := s.chain(fastReq, h, func(ctx context.Context, req, rsp interface{}) error {
+			h.raw(fastReq)
+			return nil
+		})
+		if err := realMethod(ctx, nil, nil); err != nil {
+			s.writeErrResponse(fastReq, err)
+			return
+		}
 		return
 	}
-	realMethod, req, rsp := factory()
+
+	info := h.info
+	realMethod, req, rsp := info.factory()
 
 	var reqBody []byte
-	decoder := jsonDecoder
-	isWebsocket := s.streamMethods[path]
+	var decoder, encoder coder.Codec
+	isWebsocket := info.isWebsocket
 	var stream *streamImp
 
 	doCallFunc := func() {
 		if len(reqBody) > 0 {
-			if err := decoder(reqBody, req); err != nil {
-				writeErrResponse(fastReq, &ecode.APIError{Code: 400, Message: "Decode request body failed: " + err.Error()})
+			if err := decoder.Unmarshal(reqBody, req); err != nil {
+				s.writeErrResponse(fastReq, &ecode.APIError{Code: 400, Message: "Decode request body failed: " + err.Error()})
 				return
 			}
 		}
-
-		// Middleware
-		for i := range s.middlewares {
-			mware := s.middlewares[len(s.middlewares)-i-1]
-			realMethod = func(mm middleware.MethodFunc) middleware.MethodFunc {
-				return func(ctx context.Context, req, rsp interface{}) error {
-					return mware(ctx, fastReq, mm, req, rsp)
-				}
-			}(realMethod)
+		if !isWebsocket {
+			if err := bindParams(fastReq, info.params, req); err != nil {
+				s.writeErrResponse(fastReq, err)
+				return
+			}
+			if err := checkEnums(req); err != nil {
+				s.writeErrResponse(fastReq, err)
+				return
+			}
 		}
-		err := realMethod(ctx, req, rsp)
+
+		err := s.chain(fastReq, h, realMethod)(ctx, req, rsp)
 		if isWebsocket {
 			return
 		}
 		if err != nil {
-			writeErrResponse(fastReq, err)
+			s.writeErrResponse(fastReq, err)
 			return
 		}
 
-		fastReq.Response.Header.Set("Content-Type", "application/json")
-		rspBody, err := encoder(rsp)
+		fastReq.Response.Header.SetContentType(encoder.ContentType())
+		rspBody, err := encoder.Marshal(rsp)
 		if err != nil {
-			writeErrResponse(fastReq, errors.Errorf("marshal rsp error: %v", err))
+			s.writeErrResponse(fastReq, errors.Errorf("marshal rsp error: %v", err))
 			return
 		}
 		fastReq.Write(rspBody)
 	}
 
 	if isWebsocket {
-		err := upgrader.Upgrade(fastReq, func(conn *websocket.Conn) {
+		if s.ctx.Err() != nil {
+			s.writeErrResponse(fastReq, &ecode.APIError{Code: 503, Message: "Server is shutting down"})
+			return
+		}
+		err := s.upgrader.Upgrade(fastReq, func(conn *websocket.Conn) {
 			stream = rsp.(*streamImp)
 			stream.conn = conn
+			s.streams.add(stream)
+			defer s.streams.remove(stream)
 			defer stream.close()
+			// shutdown began before the stream is tracked
+			if s.ctx.Err() != nil {
+				stream.goAway()
+			}
 			doCallFunc()
 		})
 		if err != nil {
@@ -297,11 +585,47 @@ func (s *Server) serve(fastReq *fasthttp.RequestCtx) {
 		}
 		return
 	} else {
+		if err := info.options.checkBodySize(fastReq, s.maxBodySize); err != nil {
+			s.writeErrResponse(fastReq, err)
+			return
+		}
 		reqBody = fastReq.PostBody()
 	}
+
+	contentType := string(fastReq.Request.Header.ContentType())
+	if c, ok := s.requestCodec(info, contentType); ok {
+		decoder = c
+	} else if len(reqBody) > 0 {
+		s.writeErrResponse(fastReq, &ecode.APIError{Code: 415, Message: fmt.Sprintf("Unsupported Content-Type %s, supported: %s", contentType, strings.Join(info.codecs.ContentTypes(), ", "))})
+		return
+	}
+	accept := string(fastReq.Request.Header.Peek("Accept"))
+	if c, ok := info.codecs.Negotiate(accept); ok {
+		encoder = c
+	} else {
+		s.writeErrResponse(fastReq, &ecode.APIError{Code: 406, Message: fmt.Sprintf("Not acceptable for %s, supported: %s", accept, strings.Join(info.codecs.ContentTypes(), ", "))})
+		return
+	}
 	doCallFunc()
 }
 
+// requestCodec returns codec of Content-Type, unknown media types like the default
+// application/x-www-form-urlencoded of curl are decoded by the default codec
+// unless SERVE_STRICT_CONTENT_TYPE is set
+func (s *Server) requestCodec(info *methodInfo, contentType string) (coder.Codec, bool) {
+	if c, ok := info.codecs.Get(contentType); ok {
+		return c, true
+	}
+	if _, known := coder.Get(contentType); known || s.strictContentType {
+		return nil, false
+	}
+	return info.codecs.Get("")
+}
+
+func (m *methodInfo) operationID() string {
+	return m.serviceName + m.methodName
+}
+
 func parseMethods(m *methodInfo) error {
 	method := m.methodType
 	if method.NumIn() != 4 {
@@ -372,6 +696,8 @@ func parseMethods(m *methodInfo) error {
 	} else {
 		m.reqType = req.Elem()
 		m.rspType = rsp.Elem()
+		m.params = parseBoundFields(m.reqType)
 	}
+	m.codecs = coder.For(req, rsp)
 	return nil
 }
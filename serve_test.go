@@ -0,0 +1,42 @@
+package goapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+// doRequest serves request of method and uri on the default listener of s
+func doRequest(s *Server, method, uri, body string, headers ...string) *fasthttp.RequestCtx {
+	req := &fasthttp.Request{}
+	req.Header.SetMethod(method)
+	req.SetRequestURI(uri)
+	req.SetBodyString(body)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	fastReq := &fasthttp.RequestCtx{}
+	fastReq.Init(req, nil, nil)
+	s.serve(DefaultListener, fastReq)
+	return fastReq
+}
+
+func TestRequestContentType(t *testing.T) {
+	s := NewServer()
+	s.RegisterService(&GroupService{})
+
+	// curl sends application/x-www-form-urlencoded by default
+	fastReq := doRequest(s, "POST", "/api/GroupService/Hello", `{"name":"curl"}`, "Content-Type", "application/x-www-form-urlencoded")
+	assert.Equal(t, 200, fastReq.Response.StatusCode())
+	assert.Equal(t, `{"message":"hello curl"}`, string(fastReq.Response.Body()))
+
+	fastReq = doRequest(s, "POST", "/api/GroupService/Hello", `{"name":"proto"}`, "Content-Type", "application/x-protobuf")
+	assert.Equal(t, 415, fastReq.Response.StatusCode())
+
+	s.strictContentType = true
+	fastReq = doRequest(s, "POST", "/api/GroupService/Hello", `{"name":"curl"}`, "Content-Type", "application/x-www-form-urlencoded")
+	assert.Equal(t, 415, fastReq.Response.StatusCode())
+	fastReq = doRequest(s, "POST", "/api/GroupService/Hello", `{"name":"none"}`)
+	assert.Equal(t, 200, fastReq.Response.StatusCode())
+}
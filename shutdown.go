@@ -0,0 +1,112 @@
+package goapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-errors/errors"
+)
+
+// OnShutdown registers hook called after in-flight requests and streams are
+// drained in Shutdown, hooks are called in the order of registration
+func (s *Server) OnShutdown(hook func(context.Context) error) *Server {
+	s.shutdownHooks = append(s.shutdownHooks, hook)
+	return s
+}
+
+// Shutdown stops accepting connections, sends close frames to websocket streams
+// and waits for in-flight requests and streams until ctx is done, then runs
+// the OnShutdown hooks. All errors occurred are joined and returned. Only the
+// first call shuts down the server, later calls wait for it and return its error.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() {
+		s.shutdownErr = s.shutdown(ctx)
+	})
+	return s.shutdownErr
+}
+
+func (s *Server) shutdown(ctx context.Context) error {
+	s.serveMu.Lock()
+	s.cancelFunc()
+	s.serveMu.Unlock()
+	var errs []error
+
+	s.streams.goAway()
+	for _, l := range s.listeners {
+		if l.server == nil {
+			continue
+		}
+		if err := l.server.ShutdownWithContext(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("drain http requests of listener %s: %w", l.name, err))
+		}
+	}
+	// fasthttp doesn't close the listeners it hasn't started serving on
+	s.closeListeners()
+	if err := s.streams.wait(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("drain websocket streams: %w", err))
+	}
+
+	for _, hook := range s.shutdownHooks {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// streamSet tracks the active websocket streams
+type streamSet struct {
+	mu      sync.Mutex
+	streams map[*streamImp]struct{}
+}
+
+func (ss *streamSet) add(stream *streamImp) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.streams == nil {
+		ss.streams = map[*streamImp]struct{}{}
+	}
+	ss.streams[stream] = struct{}{}
+}
+
+func (ss *streamSet) remove(stream *streamImp) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	delete(ss.streams, stream)
+}
+
+func (ss *streamSet) len() int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return len(ss.streams)
+}
+
+// goAway sends close frame to every active stream
+func (ss *streamSet) goAway() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for stream := range ss.streams {
+		stream.goAway()
+	}
+}
+
+// wait waits for all streams to finish, the remaining streams are closed if ctx is done
+func (ss *streamSet) wait(ctx context.Context) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for ss.len() > 0 {
+		select {
+		case <-ctx.Done():
+			ss.mu.Lock()
+			defer ss.mu.Unlock()
+			for stream := range ss.streams {
+				stream.forceClose()
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
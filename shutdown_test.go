@@ -0,0 +1,176 @@
+package goapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	stream "github.com/ottstack/goapi/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type EchoService struct{}
+
+// StreamEcho echoes messages until the peer closes
+func (s *EchoService) StreamEcho(ctx context.Context, req stream.RecvStream, rsp stream.SendStream) error {
+	for {
+		msg, err := req.Recv()
+		if err != nil {
+			return nil
+		}
+		if err := rsp.Send(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// startServer serves s on a random local port until ctx is done, the result of
+// ServeContext is sent to the returned channel
+func startServer(t *testing.T, ctx context.Context, s *Server) (string, <-chan error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.listeners[0].ln = ln
+	s.listeners[0].addr = ln.Addr().String()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.ServeContext(ctx)
+	}()
+	assert.Eventually(t, func() bool {
+		_, _, err := fasthttp.Get(nil, "http://"+ln.Addr().String()+"/")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	return ln.Addr().String(), done
+}
+
+// dialEcho opens a stream of EchoService and waits until it is tracked by s
+func dialEcho(t *testing.T, s *Server, addr string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/api/EchoService/StreamEcho", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "ping", string(msg))
+	assert.Equal(t, 1, s.streams.len())
+	return conn
+}
+
+func TestShutdownHooks(t *testing.T) {
+	s := NewServer()
+	var order []int
+	hookErr := errors.New("flush failed")
+	for i := 1; i <= 3; i++ {
+		i := i
+		s.OnShutdown(func(ctx context.Context) error {
+			order = append(order, i)
+			if i == 2 {
+				return hookErr
+			}
+			return nil
+		})
+	}
+
+	err := s.Shutdown(context.Background())
+	assert.ErrorIs(t, err, hookErr)
+	assert.Equal(t, []int{1, 2, 3}, order)
+	assert.Error(t, s.ctx.Err())
+
+	// later calls don't run hooks again
+	assert.Equal(t, err, s.Shutdown(context.Background()))
+	assert.Equal(t, []int{1, 2, 3}, order)
+}
+
+func TestServeContextCancel(t *testing.T) {
+	s := NewServer()
+	hooks := 0
+	s.OnShutdown(func(ctx context.Context) error {
+		hooks++
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	_, done := startServer(t, ctx, s)
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeContext is not returned after ctx is done")
+	}
+	assert.Equal(t, 1, hooks)
+}
+
+func TestServeContextShutdown(t *testing.T) {
+	s := NewServer()
+	hooks := 0
+	s.OnShutdown(func(ctx context.Context) error {
+		hooks++
+		return nil
+	})
+	_, done := startServer(t, context.Background(), s)
+	assert.NoError(t, s.Shutdown(context.Background()))
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeContext is not returned after Shutdown")
+	}
+	assert.Equal(t, 1, hooks)
+
+	// shut down server can't serve again
+	assert.EqualError(t, s.ServeContext(context.Background()), "server is shut down")
+}
+
+func TestShutdownStreams(t *testing.T) {
+	s := NewServer()
+	s.RegisterService(&EchoService{})
+	addr, done := startServer(t, context.Background(), s)
+	conn := dialEcho(t, s, addr)
+	defer conn.Close()
+
+	// the client answers close frame by default, so the stream finishes
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, s.Shutdown(ctx))
+	assert.Equal(t, 0, s.streams.len())
+	assert.NoError(t, <-done)
+}
+
+func TestStreamSetGoAway(t *testing.T) {
+	s := NewServer()
+	s.RegisterService(&EchoService{})
+	addr, _ := startServer(t, context.Background(), s)
+	defer s.Shutdown(context.Background())
+	conn := dialEcho(t, s, addr)
+	defer conn.Close()
+
+	// the peer receives close frame of going away
+	conn.SetCloseHandler(func(code int, text string) error { return nil })
+	s.streams.goAway()
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "%v", err)
+
+	// the stream is closed when ctx is done since the peer doesn't answer
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.streams.wait(ctx), context.DeadlineExceeded)
+	assert.Eventually(t, func() bool { return s.streams.len() == 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, s.streams.wait(context.Background()))
+}
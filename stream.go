@@ -1,6 +1,9 @@
 package goapi
 
 import (
+	"sync"
+	"time"
+
 	"github.com/fasthttp/websocket"
 )
 
@@ -9,8 +12,11 @@ var upgrader = websocket.FastHTTPUpgrader{
 	WriteBufferSize: 1024,
 }
 
+const closeFrameTimeout = time.Second
+
 type streamImp struct {
 	conn   *websocket.Conn
+	mu     sync.Mutex
 	closed bool
 }
 
@@ -23,17 +29,38 @@ func (s *streamImp) Send(msg []byte) error {
 	return s.conn.WriteMessage(websocket.TextMessage, msg)
 }
 
+// writeClose sends close frame, it is safe to be called concurrently with Send
+func (s *streamImp) writeClose(code int, text string) {
+	deadline := time.Now().Add(closeFrameTimeout)
+	s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), deadline)
+}
+
 func (s *streamImp) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if !s.closed {
-		s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		s.closed = true
+		s.writeClose(websocket.CloseNormalClosure, "")
 	}
 	s.conn.Close()
 }
 
-func (s *streamImp) sendErrorMessage(err error) {
-	if err == nil {
+// goAway notifies the peer that server is shutting down
+func (s *streamImp) goAway() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
 		return
 	}
 	s.closed = true
-	s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseMessage, err.Error()))
+	s.writeClose(websocket.CloseGoingAway, "server shutting down")
+}
+
+// forceClose unblocks Recv and Send of the stream, the hijacked connection is
+// closed by fasthttp after the handler returns
+func (s *streamImp) forceClose() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.conn.UnderlyingConn().SetDeadline(time.Now())
 }
@@ -0,0 +1,152 @@
+package goapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-errors/errors"
+)
+
+// tlsReloadInterval is the minimum interval to check modification of certificate files
+var tlsReloadInterval = 5 * time.Second
+
+// certReloader loads certificate and client CA from files,
+// the files are reloaded in handshake if they are changed on disk.
+type certReloader struct {
+	certFile   string
+	keyFile    string
+	caFile     string
+	clientAuth tls.ClientAuthType
+
+	mu        sync.RWMutex
+	config    *tls.Config
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newCertReloader(certFile, keyFile, caFile, clientAuth string) (*certReloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.Errorf("both SERVE_TLS_CERT and SERVE_TLS_KEY are required for TLS")
+	}
+	r := &certReloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if caFile != "" {
+		switch strings.ToLower(clientAuth) {
+		case "", "require":
+			r.clientAuth = tls.RequireAndVerifyClientCert
+		case "optional":
+			r.clientAuth = tls.VerifyClientCertIfGiven
+		default:
+			return nil, errors.Errorf("unsupported SERVE_CLIENT_AUTH %q, should be require or optional", clientAuth)
+		}
+	}
+	modTime, err := r.lastModified()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) lastModified() (time.Time, error) {
+	var last time.Time
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return last, err
+		}
+		if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last, nil
+}
+
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return errors.Errorf("load TLS certificate: %v", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   r.clientAuth,
+	}
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return errors.Errorf("load client CA: %v", err)
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return errors.Errorf("no certificate found in client CA %s", r.caFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.config = config
+	r.modTime = modTime
+	return nil
+}
+
+// reloadIfChanged reloads files if they are modified, the former config is kept if reload failed
+func (r *certReloader) reloadIfChanged() {
+	r.mu.Lock()
+	if time.Since(r.checkedAt) < tlsReloadInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.checkedAt = time.Now()
+	loaded := r.modTime
+	r.mu.Unlock()
+
+	modTime, err := r.lastModified()
+	if err != nil {
+		log.Println("Check TLS certificate error:", err)
+		return
+	}
+	if !modTime.After(loaded) {
+		return
+	}
+	if err := r.load(modTime); err != nil {
+		log.Println("Reload TLS certificate error:", err)
+		return
+	}
+	log.Println("TLS certificate reloaded")
+}
+
+func (r *certReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.reloadIfChanged()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.config, nil
+}
+
+func (r *certReloader) tlsConfig() *tls.Config {
+	return &tls.Config{GetConfigForClient: r.getConfigForClient}
+}
+
+// PeerCertificate returns the verified client certificate of mutual TLS,
+// nil is returned if the client is not verified
+func PeerCertificate(ctx context.Context) *x509.Certificate {
+	fastReq := requestCtx(ctx)
+	if fastReq == nil {
+		return nil
+	}
+	state := fastReq.TLSConnectionState()
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
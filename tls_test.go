@@ -0,0 +1,247 @@
+package goapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCert is a certificate with PEM encoded files
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert returns certificate of name signed by parent, it is self-signed CA if parent is nil
+func newTestCert(t *testing.T, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+// writeFiles writes the certificate and key to dir, the files are modified at modTime
+func (c *testCert) writeFiles(t *testing.T, dir string, modTime time.Time) (string, string) {
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	for file, data := range map[string][]byte{certFile: c.certPEM, keyFile: c.keyPEM} {
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return certFile, keyFile
+}
+
+// handshake returns the certificate served by ln to client of config
+func handshake(ln net.Listener, config *tls.Config) (*x509.Certificate, error) {
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	conn, err := tls.Dial("tcp", ln.Addr().String(), config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// client certificate is verified by server after the handshake of client in TLS 1.3
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return conn.ConnectionState().PeerCertificates[0], nil
+}
+
+func TestCertReloader(t *testing.T) {
+	interval := tlsReloadInterval
+	tlsReloadInterval = 0
+	t.Cleanup(func() { tlsReloadInterval = interval })
+
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil)
+	first := newTestCert(t, "first", ca)
+	now := time.Now()
+	certFile, keyFile := first.writeFiles(t, dir, now.Add(-time.Minute))
+	r, err := newCertReloader(certFile, keyFile, "", "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", r.tlsConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := &tls.Config{RootCAs: roots}
+
+	served, err := handshake(ln, client)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", served.Subject.CommonName)
+
+	// the rotated pair is served in the next handshake
+	second := newTestCert(t, "second", ca)
+	second.writeFiles(t, dir, now)
+	served, err = handshake(ln, client)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", served.Subject.CommonName)
+
+	// mismatched pair is not loaded and the former certificate is kept
+	if err := os.WriteFile(keyFile, first.keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(keyFile, now.Add(time.Minute), now.Add(time.Minute))
+	served, err = handshake(ln, client)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", served.Subject.CommonName)
+}
+
+func TestCertReloaderClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil)
+	certFile, keyFile := newTestCert(t, "server", ca).writeFiles(t, dir, time.Now())
+	caFile := filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(caFile, ca.certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	anonymous := &tls.Config{RootCAs: roots}
+	verified := &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{newTestCert(t, "alice", ca).tlsCertificate()}}
+	// client only sends certificate of the acceptable CAs by default
+	mallory := newTestCert(t, "mallory", nil).tlsCertificate()
+	unknown := &tls.Config{RootCAs: roots, GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return &mallory, nil
+	}}
+
+	for clientAuth, allowed := range map[string][]bool{
+		"":         {false, true, false},
+		"require":  {false, true, false},
+		"optional": {true, true, false},
+	} {
+		r, err := newCertReloader(certFile, keyFile, caFile, clientAuth)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		ln, err := tls.Listen("tcp", "127.0.0.1:0", r.tlsConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, client := range []*tls.Config{anonymous, verified, unknown} {
+			_, err := handshake(ln, client)
+			assert.Equal(t, allowed[i], err == nil, "client %d of client auth %q: %v", i, clientAuth, err)
+		}
+		ln.Close()
+	}
+
+	_, err := newCertReloader(certFile, keyFile, caFile, "request")
+	assert.EqualError(t, err, `unsupported SERVE_CLIENT_AUTH "request", should be require or optional`)
+	_, err = newCertReloader(certFile, keyFile, certFile+".missing", "")
+	assert.Error(t, err)
+	_, err = newCertReloader(certFile, "", "", "")
+	assert.Error(t, err)
+}
+
+type PeerService struct{}
+
+func (s *PeerService) Whoami(ctx context.Context, req *optionsReq, rsp *optionsRsp) error {
+	if cert := PeerCertificate(ctx); cert != nil {
+		rsp.Message = cert.Subject.CommonName
+	}
+	return nil
+}
+
+func TestPeerCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil)
+	certFile, keyFile := newTestCert(t, "server", ca).writeFiles(t, dir, time.Now())
+	caFile := filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(caFile, ca.certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r, err := newCertReloader(certFile, keyFile, caFile, "optional")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", r.tlsConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer()
+	s.RegisterService(&PeerService{})
+	s.listeners[0].ln = ln
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.ServeContext(ctx)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	whoami := func(certs ...tls.Certificate) string {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+		rsp, err := client.Post("https://"+ln.Addr().String()+"/api/PeerService/Whoami", "application/json", strings.NewReader("{}"))
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer rsp.Body.Close()
+		body, _ := io.ReadAll(rsp.Body)
+		return string(body)
+	}
+	assert.Equal(t, `{"message":"alice"}`, whoami(newTestCert(t, "alice", ca).tlsCertificate()))
+	assert.Equal(t, `{"message":""}`, whoami())
+}